@@ -0,0 +1,237 @@
+package gsql
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+// QueryBuilder builds an l8api.L8Query without building the query text, e.g.
+// gsql.Select("name").From("employee").Where(gsql.Eq("age", 30).Or(gsql.In("country", "US", "IL"))).SortBy("age").Desc().Limit(50)
+// The values are converted to literals, so they are never parsed as part of the query,
+// and string values are compared exactly, e.g. Eq("name", "*") matches only the name *.
+type QueryBuilder struct {
+	properties []string
+	rootType   string
+	where      *Criteria
+	sortBy     string
+	descending bool
+	limit      int32
+	page       int32
+	matchCase  bool
+}
+
+// Criteria is a comparator or an and/or of two criteria. And & Or group the criteria
+// by the order of the calls, i.e. Eq(a).Or(b).And(c) is (a or b) and c.
+type Criteria struct {
+	comparator *l8api.L8Comparator
+	operation  parser.ConditionOperation
+	left       *Criteria
+	right      *Criteria
+	err        error
+}
+
+// Select starts a query of the properties, no properties selects all the properties.
+func Select(properties ...string) *QueryBuilder {
+	builder := &QueryBuilder{}
+	for _, prop := range properties {
+		builder.properties = append(builder.properties, parser.TrimAndLowerNoKeys(prop))
+	}
+	return builder
+}
+
+func (this *QueryBuilder) From(rootType string) *QueryBuilder {
+	this.rootType = strings.ToLower(strings.TrimSpace(rootType))
+	return this
+}
+
+func (this *QueryBuilder) Where(criteria *Criteria) *QueryBuilder {
+	this.where = criteria
+	return this
+}
+
+// SortBy sets the sort keys, each key may have a direction, nulls order and collation, e.g. "age desc nulls last".
+func (this *QueryBuilder) SortBy(keys ...string) *QueryBuilder {
+	this.sortBy = parser.TrimAndLowerNoKeys(strings.Join(keys, ","))
+	return this
+}
+
+func (this *QueryBuilder) Desc() *QueryBuilder {
+	this.descending = true
+	return this
+}
+
+func (this *QueryBuilder) Asc() *QueryBuilder {
+	this.descending = false
+	return this
+}
+
+func (this *QueryBuilder) Limit(limit int) *QueryBuilder {
+	this.limit = int32(limit)
+	return this
+}
+
+func (this *QueryBuilder) Page(page int) *QueryBuilder {
+	this.page = int32(page)
+	return this
+}
+
+func (this *QueryBuilder) MatchCase() *QueryBuilder {
+	this.matchCase = true
+	return this
+}
+
+// Query returns the built query, or the first error of the query or its criteria.
+func (this *QueryBuilder) Query() (*l8api.L8Query, error) {
+	if this.rootType == "" {
+		return nil, errors.New("Query has no root type, use From")
+	}
+	if this.limit < 0 || this.page < 0 {
+		return nil, errors.New("Limit & page cannot be negative")
+	}
+	query := &l8api.L8Query{}
+	query.Properties = make([]string, 0, len(this.properties))
+	query.Properties = append(query.Properties, this.properties...)
+	query.RootType = this.rootType
+	query.SortBy = this.sortBy
+	query.Descending = this.descending
+	query.Limit = this.limit
+	query.Page = this.page
+	query.MatchCase = this.matchCase
+	if this.where != nil {
+		if err := this.where.error(); err != nil {
+			return nil, err
+		}
+		query.Criteria = this.where.expression()
+	}
+	query.Text = parser.Format(query)
+	return query, nil
+}
+
+// String returns the L8QL text of the query, or an empty string if the query is invalid.
+func (this *QueryBuilder) String() string {
+	query, err := this.Query()
+	if err != nil {
+		return ""
+	}
+	return query.Text
+}
+
+func Eq(property string, value interface{}) *Criteria {
+	return compare(property, parser.Eq, value)
+}
+
+func Neq(property string, value interface{}) *Criteria {
+	return compare(property, parser.Neq, value)
+}
+
+func Gt(property string, value interface{}) *Criteria {
+	return compare(property, parser.GT, value)
+}
+
+func Lt(property string, value interface{}) *Criteria {
+	return compare(property, parser.LT, value)
+}
+
+func Gte(property string, value interface{}) *Criteria {
+	return compare(property, parser.GTEQ, value)
+}
+
+func Lte(property string, value interface{}) *Criteria {
+	return compare(property, parser.LTEQ, value)
+}
+
+// In matches if the property is one of the values, a single slice value is used as the list.
+func In(property string, values ...interface{}) *Criteria {
+	return compare(property, parser.IN, list(values))
+}
+
+func NotIn(property string, values ...interface{}) *Criteria {
+	return compare(property, parser.NOTIN, list(values))
+}
+
+func list(values []interface{}) interface{} {
+	if len(values) == 1 {
+		kind := reflect.ValueOf(values[0]).Kind()
+		if kind == reflect.Slice || kind == reflect.Array {
+			return values[0]
+		}
+	}
+	return values
+}
+
+func compare(property string, operation parser.ComparatorOperation, value interface{}) *Criteria {
+	criteria := &Criteria{}
+	property = parser.TrimAndLowerNoKeys(property)
+	if property == "" {
+		criteria.err = errors.New("Criteria has no property")
+		return criteria
+	}
+	if kind := parser.OperandOf(property); kind != parser.IdentifierOperand && kind != parser.QuotedIdentifierOperand {
+		property = parser.IdentifierQuote + property + parser.IdentifierQuote
+	}
+	literal, err := parser.Literal(value)
+	if err != nil {
+		criteria.err = err
+		return criteria
+	}
+	criteria.comparator = &l8api.L8Comparator{Left: property, Oper: string(operation), Right: literal}
+	return criteria
+}
+
+func (this *Criteria) And(other *Criteria) *Criteria {
+	return &Criteria{operation: parser.And, left: this, right: other}
+}
+
+func (this *Criteria) Or(other *Criteria) *Criteria {
+	return &Criteria{operation: parser.Or, left: this, right: other}
+}
+
+func (this *Criteria) error() error {
+	if this == nil {
+		return errors.New("Criteria is nil")
+	}
+	if this.err != nil {
+		return this.err
+	}
+	if this.comparator != nil {
+		return nil
+	}
+	if err := this.left.error(); err != nil {
+		return err
+	}
+	return this.right.error()
+}
+
+// expression converts the criteria to an expression. Conditions & expressions are right associative,
+// a op b is a condition when b is a condition, otherwise the left side is grouped as a child.
+func (this *Criteria) expression() *l8api.L8Expression {
+	if cond, ok := this.condition(); ok {
+		return &l8api.L8Expression{Condition: cond}
+	}
+	expr := &l8api.L8Expression{AndOr: string(this.operation)}
+	if cond, ok := this.left.condition(); ok {
+		expr.Condition = cond
+	} else {
+		expr.Child = this.left.expression()
+	}
+	expr.Next = this.right.expression()
+	return expr
+}
+
+func (this *Criteria) condition() (*l8api.L8Condition, bool) {
+	if this.comparator != nil {
+		return &l8api.L8Condition{Comparator: this.comparator}, true
+	}
+	if this.left.comparator == nil {
+		return nil, false
+	}
+	next, ok := this.right.condition()
+	if !ok {
+		return nil, false
+	}
+	return &l8api.L8Condition{Comparator: this.left.comparator, Oper: string(this.operation), Next: next}, true
+}
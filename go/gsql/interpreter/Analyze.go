@@ -0,0 +1,106 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"time"
+)
+
+// PlanStats are the evaluation statistics of a plan node, the times are in nanoseconds.
+// GetTime is the time spent getting the values of the properties and CompareTime is the time
+// spent comparing them, both are recorded only for comparators.
+type PlanStats struct {
+	Invocations uint64        `json:"invocations"`
+	True        uint64        `json:"true"`
+	False       uint64        `json:"false"`
+	Errors      uint64        `json:"errors"`
+	Time        time.Duration `json:"time"`
+	GetTime     time.Duration `json:"getTime,omitempty"`
+	CompareTime time.Duration `json:"compareTime,omitempty"`
+}
+
+// IsAnalyze returns true if the query text had the explain analyze prefix.
+func (this *Query) IsAnalyze() bool {
+	return this.analyze
+}
+
+// Analyze evaluates the query on the elements and returns the plan with the evaluation statistics
+// of the query and of each of the where clause nodes. The evaluation is instrumented, so it is slower
+// than Filter, the statistics are meant to compare the nodes.
+func (this *Query) Analyze(list []interface{}) *Plan {
+	plan := this.Explain()
+	plan.Stats = &PlanStats{}
+	if plan.Where != nil {
+		plan.Where.initStats()
+	}
+	for _, elem := range list {
+		start := time.Now()
+		var result bool
+		var err error
+		if elem != nil {
+			if this.where == nil {
+				result = true
+			} else {
+				result, err = this.where.match(context.Background(), elem, plan.Where)
+			}
+		}
+		plan.Stats.Invocations++
+		plan.Stats.Time += time.Since(start)
+		if err != nil {
+			plan.Stats.Errors++
+		} else if result {
+			plan.Stats.True++
+		} else {
+			plan.Stats.False++
+		}
+	}
+	return plan
+}
+
+func (this *PlanNode) initStats() {
+	this.Stats = &PlanStats{}
+	for _, child := range this.Children {
+		child.initStats()
+	}
+}
+
+func (this *PlanNode) child(index int) *PlanNode {
+	if this == nil || index >= len(this.Children) {
+		return nil
+	}
+	return this.Children[index]
+}
+
+func (this *PlanNode) record(start time.Time, result *bool, err *error) {
+	this.Stats.Invocations++
+	this.Stats.Time += time.Since(start)
+	if *err != nil {
+		this.Stats.Errors++
+	} else if *result {
+		this.Stats.True++
+	} else {
+		this.Stats.False++
+	}
+}
+
+func (this *PlanStats) String() string {
+	buff := bytes.Buffer{}
+	buff.WriteString("invocations ")
+	buff.WriteString(strconv.FormatUint(this.Invocations, 10))
+	buff.WriteString(", true ")
+	buff.WriteString(strconv.FormatUint(this.True, 10))
+	buff.WriteString(", false ")
+	buff.WriteString(strconv.FormatUint(this.False, 10))
+	buff.WriteString(", errors ")
+	buff.WriteString(strconv.FormatUint(this.Errors, 10))
+	buff.WriteString(", time ")
+	buff.WriteString(this.Time.String())
+	if this.GetTime > 0 || this.CompareTime > 0 {
+		buff.WriteString(", get ")
+		buff.WriteString(this.GetTime.String())
+		buff.WriteString(", compare ")
+		buff.WriteString(this.CompareTime.String())
+	}
+	return buff.String()
+}
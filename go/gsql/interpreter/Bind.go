@@ -0,0 +1,203 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+)
+
+// Params returns the names of the query placeholders by their order in the query,
+// e.g. "1" for $1 or ? and "name" for :name.
+func (this *Query) Params() []string {
+	return this.params
+}
+
+// Bind returns an executable copy of the query with its placeholders replaced by the values.
+// The values are never parsed as part of the query text and the properties are not resolved again,
+// so a prepared query can be bound concurrently with different values. A string value is compared
+// exactly, a * or nil in it is not a wildcard or the nil keyword, see parser.Literal.
+// Numbered placeholders are bound by their number, named placeholders are bound by their order
+// in the query or by a single map[string]interface{} argument.
+// A slice value is bound as a list, e.g. for "country in ?".
+func (this *Query) Bind(args ...interface{}) (*Query, error) {
+	values, err := this.bindValues(args)
+	if err != nil {
+		return nil, err
+	}
+	bound := *this
+	bound.params = nil
+	if this.where != nil {
+		bound.where = this.where.bind(values)
+		if this.strict {
+			err = bound.where.validate(this.resources)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	bound.compile()
+	return &bound, nil
+}
+
+func (this *Query) bindValues(args []interface{}) (map[string]string, error) {
+	values := make(map[string]string)
+	lists := make(map[string]bool)
+	if this.where != nil {
+		this.where.listParams(lists)
+	}
+	if len(args) == 1 {
+		if named, ok := args[0].(map[string]interface{}); ok {
+			for _, param := range this.params {
+				value, ok := named[param]
+				if !ok {
+					return nil, errors.New("Missing value for placeholder " + param)
+				}
+				literal, err := bindLiteral(value, lists[param])
+				if err != nil {
+					return nil, err
+				}
+				values[param] = literal
+			}
+			return values, nil
+		}
+	}
+	// numbered placeholders are bound by their number, so $1 and $3 expect 3 values
+	expected := 0
+	for i, param := range this.params {
+		if n, err := strconv.Atoi(param); err == nil {
+			expected = max(expected, n)
+		} else {
+			expected = max(expected, i+1)
+		}
+	}
+	if len(args) != expected {
+		return nil, errors.New("Expected " + strconv.Itoa(expected) + " values but got " + strconv.Itoa(len(args)))
+	}
+	for i, param := range this.params {
+		value := args[i]
+		if n, err := strconv.Atoi(param); err == nil {
+			value = args[n-1]
+		}
+		literal, err := bindLiteral(value, lists[param])
+		if err != nil {
+			return nil, err
+		}
+		values[param] = literal
+	}
+	return values, nil
+}
+
+// bindLiteral returns the literal of the value, a scalar value of an in/not in placeholder is bound
+// as a list of one item, e.g. "x" is bound to "mystring in ?" as ['x'].
+func bindLiteral(value interface{}, list bool) (string, error) {
+	if list {
+		kind := reflect.Indirect(reflect.ValueOf(value)).Kind()
+		if kind != reflect.Slice && kind != reflect.Array {
+			value = []interface{}{value}
+		}
+	}
+	return parser.Literal(value)
+}
+
+// listParams adds the placeholders that are the list of an in/not in comparator.
+func (this *Expression) listParams(result map[string]bool) {
+	if this.condition != nil {
+		this.condition.listParams(result)
+	}
+	if this.child != nil {
+		this.child.listParams(result)
+	}
+	if this.next != nil {
+		this.next.listParams(result)
+	}
+}
+
+func (this *Condition) listParams(result map[string]bool) {
+	if this.comparator != nil && (this.comparator.operation == parser.IN || this.comparator.operation == parser.NOTIN) {
+		if this.comparator.rightParam != "" {
+			result[this.comparator.rightParam] = true
+		}
+	}
+	if this.next != nil {
+		this.next.listParams(result)
+	}
+}
+
+func (this *Expression) params(result []string) []string {
+	if this.condition != nil {
+		result = this.condition.params(result)
+	}
+	if this.child != nil {
+		result = this.child.params(result)
+	}
+	if this.next != nil {
+		result = this.next.params(result)
+	}
+	return result
+}
+
+func (this *Condition) params(result []string) []string {
+	if this.comparator != nil {
+		result = appendParam(result, this.comparator.leftParam)
+		result = appendParam(result, this.comparator.rightParam)
+	}
+	if this.next != nil {
+		result = this.next.params(result)
+	}
+	return result
+}
+
+func appendParam(result []string, param string) []string {
+	if param == "" {
+		return result
+	}
+	for _, p := range result {
+		if p == param {
+			return result
+		}
+	}
+	return append(result, param)
+}
+
+func (this *Expression) bind(values map[string]string) *Expression {
+	bound := *this
+	if this.condition != nil {
+		bound.condition = this.condition.bind(values)
+	}
+	if this.child != nil {
+		bound.child = this.child.bind(values)
+	}
+	if this.next != nil {
+		bound.next = this.next.bind(values)
+	}
+	return &bound
+}
+
+func (this *Condition) bind(values map[string]string) *Condition {
+	bound := *this
+	if this.comparator != nil {
+		bound.comparator = this.comparator.bind(values)
+	}
+	if this.next != nil {
+		bound.next = this.next.bind(values)
+	}
+	return &bound
+}
+
+func (this *Comparator) bind(values map[string]string) *Comparator {
+	if this.leftParam == "" && this.rightParam == "" {
+		return this
+	}
+	bound := *this
+	if this.leftParam != "" {
+		bound.left = values[this.leftParam]
+		bound.leftParam = ""
+	}
+	if this.rightParam != "" {
+		bound.right = values[this.rightParam]
+		bound.rightParam = ""
+	}
+	return &bound
+}
@@ -0,0 +1,204 @@
+package interpreter
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+const DEFAULT_CACHE_SIZE = 1024
+
+// QueryCache is a concurrency safe LRU cache of compiled queries, keyed by the normalized
+// query text and the root type. The cached queries are shared between the callers, use Bind
+// on a cached query with placeholders to get a query with values. A cached query is valid while
+// the registrations of its root type and of the types nested in it do not change, so a type
+// registered directly in the introspector also invalidates the queries it may change.
+type QueryCache struct {
+	resources ifs.IResources
+	opts      []Option
+	mtx       sync.Mutex
+	size      int
+	entries   map[cacheKey]*list.Element
+	lru       *list.List
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+type cacheKey struct {
+	text     string
+	rootType string
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	query *Query
+	types map[string]*l8reflect.L8Node
+}
+
+// CacheStats is a snapshot of the cache counters.
+type CacheStats struct {
+	Size      int
+	Capacity  int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// NewQueryCache creates a cache that compiles the queries with the options.
+func NewQueryCache(size int, resources ifs.IResources, opts ...Option) *QueryCache {
+	if size <= 0 {
+		size = DEFAULT_CACHE_SIZE
+	}
+	cache := &QueryCache{}
+	cache.resources = resources
+	cache.opts = opts
+	cache.size = size
+	cache.entries = make(map[cacheKey]*list.Element)
+	cache.lru = list.New()
+	return cache
+}
+
+// Query returns the compiled query of the text, compiling & caching it on a miss. The text is normalized
+// only for the cache key, the query is compiled from the text as is and its Text is the text of the caller.
+// Queries that fail to compile are not cached.
+func (this *QueryCache) Query(gsql string) (*Query, error) {
+	text := parser.NormalizeText(gsql)
+	key := cacheKey{text: text, rootType: parser.RootTypeOf(text)}
+
+	this.mtx.Lock()
+	elem, ok := this.entries[key]
+	if ok {
+		entry := elem.Value.(*cacheEntry)
+		if this.valid(entry) {
+			this.lru.MoveToFront(elem)
+			this.hits++
+			this.mtx.Unlock()
+			return entry.query.withText(gsql), nil
+		}
+		this.remove(elem)
+	}
+	this.misses++
+	this.mtx.Unlock()
+
+	//Compile outside of the lock so a slow compilation does not block the other callers
+	query, err := NewQuery(gsql, this.resources, this.opts...)
+	if err != nil {
+		return nil, err
+	}
+	types := this.registrations(query.rootType)
+
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	if elem, ok = this.entries[key]; ok {
+		this.lru.MoveToFront(elem)
+		return elem.Value.(*cacheEntry).query.withText(gsql), nil
+	}
+	this.entries[key] = this.lru.PushFront(&cacheEntry{key: key, query: query, types: types})
+	for this.lru.Len() > this.size {
+		this.remove(this.lru.Back())
+		this.evictions++
+	}
+	return query, nil
+}
+
+// valid checks that the root type of the query and the types nested in it were not registered,
+// or registered again, in the introspector since the query was compiled.
+func (this *QueryCache) valid(entry *cacheEntry) bool {
+	introspector := this.resources.Introspector()
+	for typeName, registered := range entry.types {
+		node, _ := introspector.Node(typeName)
+		if node != registered {
+			return false
+		}
+	}
+	return true
+}
+
+// registrations returns the nodes the introspector has for the root type and the types nested in it,
+// nil for a nested type that is not registered.
+func (this *QueryCache) registrations(rootType *l8reflect.L8Node) map[string]*l8reflect.L8Node {
+	types := make(map[string]*l8reflect.L8Node)
+	introspector := this.resources.Introspector()
+	var walk func(node *l8reflect.L8Node)
+	walk = func(node *l8reflect.L8Node) {
+		if node == nil {
+			return
+		}
+		if node.IsStruct || node == rootType {
+			if _, ok := types[node.TypeName]; ok {
+				return
+			}
+			types[node.TypeName], _ = introspector.Node(node.TypeName)
+		}
+		for _, attribute := range node.Attributes {
+			walk(attribute)
+		}
+	}
+	walk(rootType)
+	return types
+}
+
+func (this *QueryCache) remove(elem *list.Element) {
+	this.lru.Remove(elem)
+	delete(this.entries, elem.Value.(*cacheEntry).key)
+}
+
+// Inspect registers the type in the introspector and invalidates the cached queries,
+// as the new type may change how the cached queries are resolved.
+func (this *QueryCache) Inspect(any interface{}) (*l8reflect.L8Node, error) {
+	node, err := this.resources.Introspector().Inspect(any)
+	this.Purge()
+	return node, err
+}
+
+// Invalidate removes all the cached queries of the root type.
+func (this *QueryCache) Invalidate(rootType string) {
+	rootType = strings.ToLower(rootType)
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for key, elem := range this.entries {
+		entry := elem.Value.(*cacheEntry)
+		if key.rootType == rootType || strings.ToLower(entry.query.rootType.TypeName) == rootType {
+			this.remove(elem)
+		}
+	}
+}
+
+// Purge removes all the cached queries.
+func (this *QueryCache) Purge() {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.entries = make(map[cacheKey]*list.Element)
+	this.lru.Init()
+}
+
+// SetSize changes the capacity of the cache, evicting the least recently used queries if needed.
+func (this *QueryCache) SetSize(size int) {
+	if size <= 0 {
+		size = DEFAULT_CACHE_SIZE
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.size = size
+	for this.lru.Len() > this.size {
+		this.remove(this.lru.Back())
+		this.evictions++
+	}
+}
+
+func (this *QueryCache) Stats() CacheStats {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return CacheStats{
+		Size:      this.lru.Len(),
+		Capacity:  this.size,
+		Hits:      this.hits,
+		Misses:    this.misses,
+		Evictions: this.evictions,
+	}
+}
@@ -0,0 +1,207 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// COLLECTION_CACHE_SIZE is the number of compiled queries a collection keeps for Query.
+const COLLECTION_CACHE_SIZE = 128
+
+// Collection is a concurrency safe collection of elements of type T, e.g. *Employee, keyed by the
+// primary key of the type in the introspector. Query reads the collection as it is, under its read lock.
+// A snapshot is not changed by the writes that follow it, the first write after a snapshot copies the
+// collection, the elements themselves are shared and should not be modified in place.
+type Collection[T any] struct {
+	mtx           sync.Mutex
+	current       atomic.Pointer[IndexedCollection]
+	shared        bool
+	rootType      string
+	resources     ifs.IResources
+	cache         *QueryCache
+	subscriptions *Subscriptions
+}
+
+// Snapshot is a read only view of a collection at the time it was taken.
+type Snapshot[T any] struct {
+	collection *IndexedCollection
+	cache      *QueryCache
+	rootType   string
+}
+
+// NewCollection creates a collection of T, the type is registered in the introspector
+// and the queries of the collection are compiled with the options.
+func NewCollection[T any](resources ifs.IResources, opts ...Option) (*Collection[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("Collection type must be a pointer to a struct, got " + typ.String())
+	}
+	node, err := resources.Introspector().Inspect(reflect.New(typ.Elem()).Interface())
+	if err != nil {
+		return nil, err
+	}
+	collection := &Collection[T]{}
+	collection.rootType = node.TypeName
+	collection.resources = resources
+	collection.cache = NewQueryCache(COLLECTION_CACHE_SIZE, resources, opts...)
+	collection.subscriptions = NewSubscriptions()
+	current := NewIndexedCollection(node.TypeName, resources)
+	// the collection is keyed by the primary key, so the primary key lookups are answered by the keys
+	current.primary = true
+	collection.current.Store(current)
+	return collection, nil
+}
+
+// writable returns the current collection to write to, a copy if a snapshot may be reading it.
+func (this *Collection[T]) writable() *IndexedCollection {
+	current := this.current.Load()
+	if this.shared {
+		current = current.clone()
+		this.current.Store(current)
+		this.shared = false
+	}
+	return current
+}
+
+// AddIndex adds an index of the kind on the property path of the elements, see IndexedCollection.
+func (this *Collection[T]) AddIndex(path string, kind IndexKind) error {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	return this.writable().AddIndex(path, kind)
+}
+
+// Put inserts the element, or replaces the element with the same primary key.
+func (this *Collection[T]) Put(elem T) error {
+	key, err := this.keyOf(elem)
+	if err != nil {
+		return err
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	current := this.writable()
+	previous, _ := current.Get(key)
+	current.Put(key, elem)
+	this.subscriptions.Put(key, previous, elem)
+	return nil
+}
+
+// Delete removes the element of the primary key and returns true if there was one.
+func (this *Collection[T]) Delete(key string) bool {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	previous, ok := this.current.Load().Get(key)
+	if !ok {
+		return false
+	}
+	this.writable().Delete(key)
+	this.subscriptions.Delete(key, previous)
+	return true
+}
+
+// Get returns the element of the primary key.
+func (this *Collection[T]) Get(key string) (T, bool) {
+	return typed[T](this.current.Load().Get(key))
+}
+
+// Len returns the number of elements.
+func (this *Collection[T]) Len() int {
+	return this.current.Load().Len()
+}
+
+// Snapshot returns a read only view of the collection as it is now.
+func (this *Collection[T]) Snapshot() *Snapshot[T] {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	this.shared = true
+	return &Snapshot[T]{collection: this.current.Load(), cache: this.cache, rootType: this.rootType}
+}
+
+// Query runs the query text on the collection, see Snapshot.Query. The matching elements are taken
+// under the read lock of the collection, with no snapshot, so the next write does not copy the collection.
+func (this *Collection[T]) Query(gsql string) ([]T, error) {
+	view := &Snapshot[T]{collection: this.current.Load(), cache: this.cache, rootType: this.rootType}
+	return view.Query(gsql)
+}
+
+// Subscribe registers the query text, the handler is called with the changes of the elements that
+// match it, see Subscriptions. The handler is called by Put & Delete while they hold the write lock,
+// so it must not write to the collection.
+func (this *Collection[T]) Subscribe(gsql string, onlySelectedColumns bool, handler func(*Change)) (*Subscription, error) {
+	query, err := this.cache.Query(gsql)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(query.RootType().TypeName, this.rootType) {
+		return nil, errors.New("Query of " + query.RootType().TypeName + " cannot subscribe to a collection of " + this.rootType)
+	}
+	return this.subscriptions.Subscribe(query, onlySelectedColumns, handler), nil
+}
+
+// KeyOf returns the primary key of the element.
+func (this *Collection[T]) KeyOf(elem T) (string, error) {
+	return this.keyOf(elem)
+}
+
+func (this *Collection[T]) keyOf(elem T) (string, error) {
+	key, _, err := this.resources.Introspector().Decorators().PrimaryKeyDecoratorValue(elem)
+	if err != nil {
+		return "", errors.New("Cannot get the primary key of " + this.rootType + ": " + err.Error())
+	}
+	return key, nil
+}
+
+// Get returns the element of the primary key.
+func (this *Snapshot[T]) Get(key string) (T, bool) {
+	return typed[T](this.collection.Get(key))
+}
+
+// Len returns the number of elements.
+func (this *Snapshot[T]) Len() int {
+	return this.collection.Len()
+}
+
+// Query compiles the query text, or takes it from the query cache of the collection, and runs it.
+func (this *Snapshot[T]) Query(gsql string) ([]T, error) {
+	query, err := this.cache.Query(gsql)
+	if err != nil {
+		return nil, err
+	}
+	return this.Execute(query)
+}
+
+// Execute returns the elements that match the query, sorted & paged by the query,
+// with only the selected columns.
+func (this *Snapshot[T]) Execute(query *Query) ([]T, error) {
+	if !strings.EqualFold(query.RootType().TypeName, this.rootType) {
+		return nil, errors.New("Query of " + query.RootType().TypeName + " cannot run on a collection of " + this.rootType)
+	}
+	page := query.SortAndPage(this.collection.Filter(query, false))
+	result := make([]T, 0, len(page))
+	for _, elem := range page {
+		t, ok := query.project(elem).(T)
+		if !ok {
+			return nil, errors.New("Unexpected element type " + reflect.TypeOf(elem).String())
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// Explain returns the plan of the query, with the index usage of the snapshot.
+func (this *Snapshot[T]) Explain(query *Query) *Plan {
+	return this.collection.Explain(query)
+}
+
+func typed[T any](elem interface{}, ok bool) (T, bool) {
+	var zero T
+	if !ok {
+		return zero, false
+	}
+	t, ok := elem.(T)
+	return t, ok
+}
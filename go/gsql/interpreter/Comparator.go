@@ -2,7 +2,10 @@ package interpreter
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/saichler/l8ql/go/gsql/interpreter/comparators"
 	"github.com/saichler/l8ql/go/gsql/parser"
@@ -15,9 +18,13 @@ import (
 type Comparator struct {
 	left          string
 	leftProperty  *properties.Property
+	leftPath      *accessPath
+	leftParam     string
 	operation     parser.ComparatorOperation
 	right         string
 	rightProperty *properties.Property
+	rightPath     *accessPath
+	rightParam    string
 }
 
 type Comparable interface {
@@ -25,9 +32,10 @@ type Comparable interface {
 }
 
 var comparables = make(map[parser.ComparatorOperation]Comparable)
+var comparablesOnce = sync.Once{}
 
 func initComparables() {
-	if len(comparables) == 0 {
+	comparablesOnce.Do(func() {
 		comparables[parser.Eq] = comparators.NewEqual()
 		comparables[parser.Neq] = comparators.NewNotEqual()
 		comparables[parser.NOTIN] = comparators.NewNotIN()
@@ -36,19 +44,23 @@ func initComparables() {
 		comparables[parser.LT] = comparators.NewLessThan()
 		comparables[parser.GTEQ] = comparators.NewGreaterThanOrEqual()
 		comparables[parser.LTEQ] = comparators.NewLessThanOrEqual()
-	}
+	})
 }
 
 func (this *Comparator) String() string {
 	buff := bytes.Buffer{}
-	if this.leftProperty != nil {
+	if this.leftPath != nil {
+		buff.WriteString(this.leftPath.String())
+	} else if this.leftProperty != nil {
 		pid, _ := this.leftProperty.PropertyId()
 		buff.WriteString(pid)
 	} else {
 		buff.WriteString(this.left)
 	}
 	buff.WriteString(string(this.operation))
-	if this.rightProperty != nil {
+	if this.rightPath != nil {
+		buff.WriteString(this.rightPath.String())
+	} else if this.rightProperty != nil {
 		pid, _ := this.rightProperty.PropertyId()
 		buff.WriteString(pid)
 	} else {
@@ -57,46 +69,150 @@ func (this *Comparator) String() string {
 	return buff.String()
 }
 
-func CreateComparator(c *l8api.L8Comparator, rootTable *l8reflect.L8Node, resources ifs.IResources) (*Comparator, error) {
+func CreateComparator(c *l8api.L8Comparator, rootTable *l8reflect.L8Node, resources ifs.IResources, opts ...Option) (*Comparator, error) {
 	initComparables()
+	options := newOptions(opts)
 	ormComp := &Comparator{}
 	ormComp.operation = parser.ComparatorOperation(c.Oper)
 	ormComp.left = c.Left
 	ormComp.right = c.Right
-	leftProp := propertyPath(ormComp.left, rootTable.TypeName)
-	rightProp := propertyPath(ormComp.right, rootTable.TypeName)
-	ormComp.leftProperty, _ = properties.PropertyOf(leftProp, resources)
-	ormComp.rightProperty, _ = properties.PropertyOf(rightProp, resources)
+	ormComp.leftParam, _ = parser.Placeholder(ormComp.left)
+	ormComp.rightParam, _ = parser.Placeholder(ormComp.right)
+	if ormComp.leftParam != "" && ormComp.rightParam != "" {
+		return nil, errors.New("Both sides of comparator are placeholders: " + c.String())
+	}
+	if ormComp.leftParam == "" {
+		prop, path, err := resolveOperand(ormComp.left, rootTable, resources, options.strict)
+		if err != nil {
+			return nil, err
+		}
+		ormComp.leftProperty, ormComp.leftPath = prop, path
+	}
+	if ormComp.rightParam == "" {
+		prop, path, err := resolveOperand(ormComp.right, rootTable, resources, options.strict)
+		if err != nil {
+			return nil, err
+		}
+		ormComp.rightProperty, ormComp.rightPath = prop, path
+	}
 
-	if ormComp.leftProperty == nil && ormComp.rightProperty == nil {
-		return nil, errors.New("No Field was found for comparator: " + c.String())
+	if ormComp.leftProperty == nil && ormComp.rightProperty == nil && !ormComp.isConstant() {
+		suggestion := suggestProperty(c.Left, rootTable)
+		if suggestion == "" {
+			suggestion = suggestProperty(c.Right, rootTable)
+		}
+		return nil, errors.New("No Field was found for comparator: " + c.String() + suggestion)
 	}
 	return ormComp, nil
 }
 
+// isConstant returns true if both sides of the comparator are literals, e.g. 1=2.
+func (this *Comparator) isConstant() bool {
+	return this.leftProperty == nil && this.rightProperty == nil && this.leftParam == "" && this.rightParam == "" &&
+		parser.OperandOf(this.left) != parser.IdentifierOperand && parser.OperandOf(this.right) != parser.IdentifierOperand
+}
+
+// resolveOperand returns the property of the operand, or nil if the operand is a literal.
+// A quoted identifier must resolve, a bare identifier must resolve only in strict mode,
+// otherwise it is compared as a literal.
+func resolveOperand(operand string, rootTable *l8reflect.L8Node, resources ifs.IResources, resolve bool) (*properties.Property, *accessPath, error) {
+	kind := parser.OperandOf(operand)
+	if kind != parser.IdentifierOperand && kind != parser.QuotedIdentifierOperand {
+		return nil, nil, nil
+	}
+	mustResolve := resolve || kind == parser.QuotedIdentifierOperand
+	name := parser.Identifier(operand)
+	if parser.IsAccessPath(name) {
+		path, err := newAccessPath(name, rootTable.TypeName, resources)
+		if err != nil {
+			if !mustResolve {
+				return nil, nil, nil
+			}
+			return nil, nil, errors.New(err.Error() + suggestProperty(name, rootTable))
+		}
+		return path.property, path, nil
+	}
+	prop, err := properties.PropertyOf(propertyPath(name, rootTable.TypeName), resources)
+	if err != nil && mustResolve {
+		return nil, nil, errors.New("Unknown field " + name + " in " + rootTable.TypeName + suggestProperty(name, rootTable))
+	}
+	return prop, nil, nil
+}
+
 func (this *Comparator) Match(root interface{}) (bool, error) {
+	return this.match(context.Background(), root, nil)
+}
+
+// match evaluates the comparator, recording the evaluation statistics in the plan node if it is not nil,
+// the time spent getting the values is recorded apart from the time spent comparing them.
+func (this *Comparator) match(ctx context.Context, root interface{}, node *PlanNode) (result bool, err error) {
+	if node != nil {
+		defer node.record(time.Now(), &result, &err)
+	}
+	var start time.Time
+	if node != nil {
+		start = time.Now()
+	}
+	leftValue, rightValue, err := this.values(ctx, root)
+	if err != nil {
+		return false, err
+	}
+	if node != nil {
+		node.Stats.GetTime += time.Since(start)
+		start = time.Now()
+	}
+	result = this.compare(leftValue, rightValue)
+	if node != nil {
+		node.Stats.CompareTime += time.Since(start)
+	}
+	return result, nil
+}
+
+func (this *Comparator) values(ctx context.Context, root interface{}) (interface{}, interface{}, error) {
 	var leftValue interface{}
 	var rightValue interface{}
 	var err error
-	if this.leftProperty != nil {
+	if this.leftParam != "" || this.rightParam != "" {
+		return nil, nil, errors.New("Unbound placeholder in comparator: " + this.String())
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if this.leftPath != nil {
+		leftValue, err = this.leftPath.get(ctx, root)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if this.leftProperty != nil {
 		leftValue, err = this.leftProperty.Get(root)
 		if err != nil {
-			return false, err
+			return nil, nil, err
 		}
 	} else {
 		leftValue = this.left
 	}
-	if this.rightProperty != nil {
+	if this.rightPath != nil {
+		rightValue, err = this.rightPath.get(ctx, root)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else if this.rightProperty != nil {
 		rightValue, err = this.rightProperty.Get(root)
-		return false, err
+		if err != nil {
+			return nil, nil, err
+		}
 	} else {
 		rightValue = this.right
 	}
+	return leftValue, rightValue, nil
+}
+
+func (this *Comparator) compare(leftValue, rightValue interface{}) bool {
 	matcher := comparables[this.operation]
 	if matcher == nil {
 		panic("No Matcher for: " + this.operation + " operation.")
 	}
-	return matcher.Compare(leftValue, rightValue), nil
+	return matcher.Compare(leftValue, rightValue)
 }
 
 func (this *Comparator) Left() string {
@@ -118,13 +234,3 @@ func (this *Comparator) RightProperty() ifs.IProperty {
 func (this *Comparator) Operator() string {
 	return string(this.operation)
 }
-
-func (this *Comparator) keyOf() string {
-	if this.leftProperty == nil {
-		return this.left
-	}
-	if this.rightProperty == nil {
-		return this.right
-	}
-	return ""
-}
@@ -0,0 +1,114 @@
+package interpreter
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter/comparators"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// matchFunc is a where clause compiled into closures, it evaluates as Expression.Match.
+type matchFunc func(ctx context.Context, root interface{}) (bool, error)
+
+// Compilable is a Comparable that can bind its compare function to the kind of a property
+// and a pre-parsed literal when the query is compiled.
+type Compilable interface {
+	Compile(kind reflect.Kind, literal string) comparators.Matcher
+}
+
+// compile compiles the where clause of the query, it is called whenever the where clause is replaced.
+func (this *Query) compile() {
+	this.matcher = nil
+	if this.where != nil {
+		this.matcher = this.where.compile(this.resources)
+	}
+}
+
+func (this *Expression) compile(resources ifs.IResources) matchFunc {
+	if this.operation != "" && this.operation != parser.And && this.operation != parser.Or {
+		return func(ctx context.Context, root interface{}) (bool, error) {
+			return this.match(ctx, root, nil)
+		}
+	}
+	operands := make([]matchFunc, 0, 3)
+	if this.condition != nil {
+		operands = append(operands, this.condition.compile(resources))
+	}
+	if this.child != nil {
+		operands = append(operands, this.child.compile(resources))
+	}
+	if this.next != nil {
+		operands = append(operands, this.next.compile(resources))
+	}
+	return shortCircuit(this.operation != parser.Or, operands)
+}
+
+func (this *Condition) compile(resources ifs.IResources) matchFunc {
+	if this.comparator == nil || this.operation != "" && this.operation != parser.And && this.operation != parser.Or {
+		return func(ctx context.Context, root interface{}) (bool, error) {
+			return this.match(ctx, root, nil)
+		}
+	}
+	operands := []matchFunc{this.comparator.compile(resources)}
+	if this.next != nil {
+		operands = append(operands, this.next.compile(resources))
+	}
+	return shortCircuit(this.operation != parser.Or, operands)
+}
+
+// shortCircuit evaluates the operands in order until an operand decides the result,
+// a false operand of an and or a true operand of an or.
+func shortCircuit(and bool, operands []matchFunc) matchFunc {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	return func(ctx context.Context, root interface{}) (bool, error) {
+		for _, operand := range operands {
+			result, err := operand(ctx, root)
+			if err != nil {
+				return false, err
+			}
+			if result != and {
+				return result, nil
+			}
+		}
+		return and, nil
+	}
+}
+
+// compile binds the compare function for the kind of a scalar property compared to a literal,
+// with the literal parsed once. Other comparators, e.g. paths or two properties, are evaluated by Match.
+func (this *Comparator) compile(resources ifs.IResources) matchFunc {
+	interpreted := func(ctx context.Context, root interface{}) (bool, error) {
+		return this.match(ctx, root, nil)
+	}
+	if this.leftProperty == nil || this.leftPath != nil || this.rightProperty != nil ||
+		this.leftParam != "" || this.rightParam != "" {
+		return interpreted
+	}
+	node := this.leftProperty.Node()
+	if node == nil || !isScalar(node) {
+		return interpreted
+	}
+	compilable, ok := comparables[this.operation].(Compilable)
+	if !ok {
+		return interpreted
+	}
+	matcher := compilable.Compile(kindOf(node, resources), this.right)
+	if matcher == nil {
+		return interpreted
+	}
+	property, right := this.leftProperty, this.right
+	return func(ctx context.Context, root interface{}) (bool, error) {
+		value, err := property.Get(root)
+		if err != nil {
+			return false, err
+		}
+		if result, ok := matcher(value); ok {
+			return result, nil
+		}
+		return this.compare(value, right), nil
+	}
+}
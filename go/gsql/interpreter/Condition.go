@@ -2,7 +2,9 @@ package interpreter
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"time"
 
 	"github.com/saichler/l8ql/go/gsql/parser"
 	"github.com/saichler/l8types/go/ifs"
@@ -16,16 +18,16 @@ type Condition struct {
 	next       *Condition
 }
 
-func CreateCondition(c *l8api.L8Condition, rootTable *l8reflect.L8Node, resources ifs.IResources) (*Condition, error) {
+func CreateCondition(c *l8api.L8Condition, rootTable *l8reflect.L8Node, resources ifs.IResources, opts ...Option) (*Condition, error) {
 	condition := &Condition{}
 	condition.operation = parser.ConditionOperation(c.Oper)
-	comp, e := CreateComparator(c.Comparator, rootTable, resources)
+	comp, e := CreateComparator(c.Comparator, rootTable, resources, opts...)
 	if e != nil {
 		return nil, e
 	}
 	condition.comparator = comp
 	if c.Next != nil {
-		next, e := CreateCondition(c.Next, rootTable, resources)
+		next, e := CreateCondition(c.Next, rootTable, resources, opts...)
 		if e != nil {
 			return nil, e
 		}
@@ -53,16 +55,27 @@ func (this *Condition) toString(buff *bytes.Buffer) {
 }
 
 func (this *Condition) Match(root interface{}) (bool, error) {
-	comp, e := this.comparator.Match(root)
+	return this.match(context.Background(), root, nil)
+}
+
+// match evaluates the condition, recording the evaluation statistics in the plan node if it is not nil.
+func (this *Condition) match(ctx context.Context, root interface{}, node *PlanNode) (result bool, err error) {
+	if node != nil {
+		defer node.record(time.Now(), &result, &err)
+	}
+	comp, e := this.comparator.match(ctx, root, node.child(0))
 	if e != nil {
 		return false, e
 	}
+	if shortCircuits(this.operation, comp) {
+		return comp, nil
+	}
 	next := true
 	if this.operation == parser.Or {
 		next = false
 	}
 	if this.next != nil {
-		next, e = this.next.Match(root)
+		next, e = this.next.match(ctx, root, node.child(1))
 		if e != nil {
 			return false, e
 		}
@@ -90,13 +103,3 @@ func (this *Condition) Operator() string {
 func (this *Condition) Next() ifs.ICondition {
 	return this.next
 }
-
-func (this *Condition) keyOf() string {
-	if this.comparator != nil {
-		return this.comparator.keyOf()
-	}
-	if this.next != nil {
-		return this.next.keyOf()
-	}
-	return ""
-}
@@ -0,0 +1,75 @@
+package interpreter
+
+import (
+	"context"
+	"time"
+)
+
+// CHECK_INTERVAL is the number of elements FilterCtx matches between checks of the context.
+const CHECK_INTERVAL = 256
+
+// Timeout sets the deadline of each MatchCtx call and of each FilterCtx & FilterParallel call.
+func Timeout(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.timeout = timeout
+	}
+}
+
+// Timeout returns the deadline of the query executions, zero if there is none.
+func (this *Query) Timeout() time.Duration {
+	return this.timeout
+}
+
+// withTimeout returns the context with the query deadline, if the query has one.
+func (this *Query) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if this.timeout > 0 {
+		return context.WithTimeout(ctx, this.timeout)
+	}
+	return ctx, func() {}
+}
+
+// MatchCtx is Match that stops when the context is done. The context is checked before each comparator
+// gets its values and, for the paths with key accessors or path functions, e.g. mymodelslice[0:].mystring,
+// for each of the values the path fans out to. The context error is returned when the match stops.
+func (this *Query) MatchCtx(ctx context.Context, any interface{}) (bool, error) {
+	ctx, cancel := this.withTimeout(ctx)
+	defer cancel()
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return this.match(ctx, any)
+}
+
+// FilterCtx is Filter that stops when the context is done, the context is checked every CHECK_INTERVAL
+// elements and while matching an element. The context error is returned when the filter stops.
+func (this *Query) FilterCtx(ctx context.Context, list []interface{}, onlySelectedColumns bool) ([]interface{}, error) {
+	ctx, cancel := this.withTimeout(ctx)
+	defer cancel()
+	return this.filter(ctx, list, onlySelectedColumns)
+}
+
+func (this *Query) filter(ctx context.Context, list []interface{}, onlySelectedColumns bool) ([]interface{}, error) {
+	result := make([]interface{}, 0)
+	for index, i := range list {
+		if index%CHECK_INTERVAL == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		m, e := this.match(ctx, i)
+		if e != nil {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			this.resources.Logger().Error(e)
+		}
+		if m {
+			if !onlySelectedColumns || len(this.properties) == 0 {
+				result = append(result, i)
+			} else {
+				result = append(result, this.cloneOnlyWithColumns(i))
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package interpreter
+
+import (
+	"math"
+	"sort"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// FAN_OUT is the assumed number of elements of a list or a map, without statistics.
+const FAN_OUT = 10
+
+// Reordered reorders the where clause by the estimated cost when the query is compiled, see Query.Reorder.
+func Reordered() Option {
+	return func(opts *options) {
+		opts.reorder = true
+	}
+}
+
+// Reorder returns a copy of the query with the operands of each and/or group ordered so the cheap and
+// selective predicates are evaluated first. An error of an operand fails the whole match, so an operand
+// that can fail, i.e. a path with keys or functions, a field under a list or a map or a placeholder,
+// keeps its position and the other operands move only between the operands that can fail, as and/or
+// short circuit the result of the where clause is then the same. A timeout may still be reached at another operand.
+// The cost of a comparator is estimated from the depth of its paths and the fan-out of the lists &
+// maps they traverse, a top level scalar is cheap while a path over a map of lists is costly.
+func (this *Query) Reorder() *Query {
+	reordered := *this
+	if this.where == nil {
+		return &reordered
+	}
+	node := this.where.normalize()
+	node.reorder()
+	reordered.where = node.expression()
+	reordered.compile()
+	return &reordered
+}
+
+// reorder sorts the operands of the group by their rank and returns the expected cost & the selectivity
+// of the group and if it can fail. The expected cost of an and group is c1 + s1*c2 + s1*s2*c3..., so an
+// operand is ranked by cost/(1-selectivity), and by cost/selectivity in an or group. The operands that
+// can fail are not sorted, only the operands between them are.
+func (this *normalNode) reorder() (float64, float64, bool) {
+	if this.isLeaf() {
+		if this.constant {
+			return 1, 1, false
+		}
+		return this.comparator.cost(), this.comparator.selectivity(), this.comparator.fallible()
+	}
+	costs := make(map[*normalNode]float64, len(this.children))
+	selectivities := make(map[*normalNode]float64, len(this.children))
+	fallible := false
+	var barriers []int
+	for i, child := range this.children {
+		var fails bool
+		costs[child], selectivities[child], fails = child.reorder()
+		if fails {
+			fallible = true
+			barriers = append(barriers, i)
+		}
+	}
+	rank := func(child *normalNode) float64 {
+		decisive := 1 - selectivities[child]
+		if this.operation == parser.Or {
+			decisive = selectivities[child]
+		}
+		if decisive <= 0 {
+			return math.Inf(1)
+		}
+		return costs[child] / decisive
+	}
+	from := 0
+	for _, barrier := range append(barriers, len(this.children)) {
+		segment := this.children[from:barrier]
+		sort.SliceStable(segment, func(i, j int) bool {
+			return rank(segment[i]) < rank(segment[j])
+		})
+		from = barrier + 1
+	}
+	cost, reached := 0.0, 1.0
+	for _, child := range this.children {
+		cost += reached * costs[child]
+		if this.operation == parser.Or {
+			reached *= 1 - selectivities[child]
+		} else {
+			reached *= selectivities[child]
+		}
+	}
+	if this.operation == parser.Or {
+		return cost, 1 - reached, fallible
+	}
+	return cost, reached, fallible
+}
+
+// fallible returns true if getting the values of the comparator can fail, e.g. an invalid key of a list,
+// a field under a list or a map or an unbound placeholder.
+func (this *Comparator) fallible() bool {
+	if this.leftPath != nil || this.rightPath != nil || this.leftParam != "" || this.rightParam != "" {
+		return true
+	}
+	return underCollection(this.leftProperty) || underCollection(this.rightProperty)
+}
+
+// underCollection returns true if the property is a field of an element of a list or a map.
+func underCollection(property *properties.Property) bool {
+	if property == nil || property.Node() == nil {
+		return false
+	}
+	for node := property.Node().Parent; node != nil; node = node.Parent {
+		if node.IsSlice || node.IsMap {
+			return true
+		}
+	}
+	return false
+}
+
+// cost estimates the cost of the comparator as the number of values it gets & compares.
+func (this *Comparator) cost() float64 {
+	return 1 + operandCost(this.leftProperty, this.leftPath) + operandCost(this.rightProperty, this.rightPath)
+}
+
+// operandCost is the number of fields the operand walks, each field under a list or a map is walked
+// once per element unless the path selects a single element, e.g. mymodelslice[0] or mymap['key'].
+func operandCost(property *properties.Property, path *accessPath) float64 {
+	if property == nil {
+		return 0
+	}
+	leaf := property.Node()
+	if leaf == nil {
+		return 1
+	}
+	nodes := make([]*l8reflect.L8Node, 0)
+	for node := leaf; node != nil && node.Parent != nil; node = node.Parent {
+		nodes = append([]*l8reflect.L8Node{node}, nodes...)
+	}
+	cost, fan := 0.0, 1.0
+	for i, node := range nodes {
+		cost += fan
+		if !node.IsSlice && !node.IsMap {
+			continue
+		}
+		if path != nil && i < len(path.elements) && path.elements[i].Key != nil {
+			if _, isRange := path.elements[i].Key.Range(); !isRange {
+				continue
+			}
+		}
+		fan *= FAN_OUT
+	}
+	if path != nil && path.function != "" {
+		cost += fan
+	}
+	return cost
+}
+
+// combineCost is the expected cost of evaluating the children in order with short circuit.
+func combineCost(operation parser.ConditionOperation, children []*PlanNode) float64 {
+	cost, reached := 0.0, 1.0
+	for _, child := range children {
+		cost += reached * child.Cost
+		if operation == parser.Or {
+			reached *= 1 - child.Selectivity
+		} else {
+			reached *= child.Selectivity
+		}
+	}
+	return cost
+}
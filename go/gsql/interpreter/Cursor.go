@@ -0,0 +1,262 @@
+package interpreter
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+var processSecret []byte
+var processSecretMtx = sync.Mutex{}
+
+// CursorSecret sets the secret the cursors of the query are signed with. Without a secret the cursors
+// are signed with a random secret of the process, so they are valid only in the process that created them.
+func CursorSecret(secret []byte) Option {
+	return func(opts *options) {
+		opts.cursorSecret = secret
+	}
+}
+
+// secret returns the secret of the query, or the secret of the process, which is created on first use.
+func (this *Query) secret() ([]byte, error) {
+	if len(this.cursorSecret) > 0 {
+		return this.cursorSecret, nil
+	}
+	processSecretMtx.Lock()
+	defer processSecretMtx.Unlock()
+	if processSecret == nil {
+		secret := make([]byte, 32)
+		_, err := rand.Read(secret)
+		if err != nil {
+			return nil, errors.New("Cannot create the cursor secret: " + err.Error())
+		}
+		processSecret = secret
+	}
+	return processSecret, nil
+}
+
+// cursor is the position of the last row of a page, the values of its sort keys and its primary key.
+type cursor struct {
+	Scope  string         `json:"s"`
+	Values []*cursorValue `json:"v"`
+	Key    string         `json:"k"`
+}
+
+// cursorValue is a sort value with its kind, so it is decoded to a value of the same kind.
+type cursorValue struct {
+	Kind  string `json:"t"`
+	Value string `json:"v,omitempty"`
+}
+
+// After returns a copy of the query that resumes after the row of the cursor, see KeysetPage.
+// The cursor must have been created by a query with the same root type, where clause & sort-by
+// and signed with the same secret.
+func (this *Query) After(token string) (*Query, error) {
+	data, err := hex.DecodeString(token)
+	if err != nil || len(data) <= sha256.Size {
+		return nil, errors.New("Invalid cursor " + token)
+	}
+	payload, signature := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+	expected, err := this.sign(payload)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(signature, expected) {
+		return nil, errors.New("Invalid cursor signature")
+	}
+	position := &cursor{}
+	err = json.Unmarshal(payload, position)
+	if err != nil {
+		return nil, errors.New("Invalid cursor: " + err.Error())
+	}
+	if position.Scope != this.cursorScope() {
+		return nil, errors.New("The cursor was created by another query")
+	}
+	if len(position.Values) != len(this.sortKeys) {
+		return nil, errors.New("Invalid cursor, expected " + strconv.Itoa(len(this.sortKeys)) + " sort values")
+	}
+	item := sortItem{key: position.Key, seq: -1}
+	for i, value := range position.Values {
+		v, er := value.decode()
+		if er != nil {
+			return nil, er
+		}
+		if i == 0 {
+			item.value = v
+		} else {
+			item.more = append(item.more, v)
+		}
+	}
+	after := *this
+	after.after = &item
+	return &after, nil
+}
+
+// KeysetPage returns the limit rows that match the query after the row of the cursor, if the query
+// has one, by the sort-by and then by the primary key, and the cursor of the last row. The cursor is
+// empty when there are no more rows. Unlike page, the next page starts exactly after the last row
+// even if rows were added or removed. The page of the query is ignored.
+func (this *Query) KeysetPage(list []interface{}) ([]interface{}, string, error) {
+	decorators := this.resources.Introspector().Decorators()
+	var top *topK
+	if this.limit > 0 {
+		top = newTopK(this, int(this.limit)+1)
+	}
+	items := make([]sortItem, 0)
+	for i, elem := range list {
+		if !this.Match(elem) {
+			continue
+		}
+		key, _, err := decorators.PrimaryKeyDecoratorValue(elem)
+		if err != nil {
+			return nil, "", errors.New("Keyset pagination needs a primary key: " + err.Error())
+		}
+		item := this.sortItem(elem, i)
+		item.key = key
+		if this.after != nil && this.compareItems(item, *this.after) <= 0 {
+			continue
+		}
+		if top != nil {
+			top.add(item)
+		} else {
+			items = append(items, item)
+		}
+	}
+	if top != nil {
+		items = top.sorted()
+	} else {
+		this.sort(items)
+	}
+	next := ""
+	if this.limit > 0 && len(items) > int(this.limit) {
+		items = items[:this.limit]
+		token, err := this.cursorOf(items[len(items)-1])
+		if err != nil {
+			return nil, "", err
+		}
+		next = token
+	}
+	result := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		result = append(result, this.project(item.elem))
+	}
+	return result, next, nil
+}
+
+func (this *Query) cursorOf(item sortItem) (string, error) {
+	position := this.positionOf(item)
+	position.Scope = this.cursorScope()
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	signature, err := this.sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(append(payload, signature...)), nil
+}
+
+// positionOf returns the position of the row, with its sort values & its primary key.
+func (this *Query) positionOf(item sortItem) *cursor {
+	position := &cursor{Key: item.key}
+	if len(this.sortKeys) > 0 {
+		position.Values = append(position.Values, encodeCursorValue(item.value))
+		for _, v := range item.more {
+			position.Values = append(position.Values, encodeCursorValue(v))
+		}
+	}
+	return position
+}
+
+// afterCanonical returns the position the query resumes after, or an empty string if the query has no cursor.
+func (this *Query) afterCanonical() string {
+	if this.after == nil {
+		return ""
+	}
+	data, _ := json.Marshal(this.positionOf(*this.after))
+	return string(data)
+}
+
+func (this *Query) sign(payload []byte) ([]byte, error) {
+	secret, err := this.secret()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+// cursorScope digests the parts of the query that define the rows & their order,
+// so a cursor is not used with another query.
+func (this *Query) cursorScope() string {
+	buff := bytes.Buffer{}
+	if this.rootType != nil {
+		buff.WriteString(this.rootType.TypeName)
+	}
+	buff.WriteString("|")
+	if this.where != nil {
+		buff.WriteString(this.where.normalize().canonical())
+	}
+	buff.WriteString("|")
+	buff.WriteString(this.sortBy)
+	buff.WriteString("|")
+	buff.WriteString(strconv.FormatBool(this.descending))
+	buff.WriteString(strconv.FormatBool(this.matchCase))
+	sum := sha256.Sum256(buff.Bytes())
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeCursorValue(v interface{}) *cursorValue {
+	if isNull(v) {
+		return &cursorValue{Kind: "n"}
+	}
+	value := reflect.ValueOf(v)
+	switch {
+	case value.CanInt():
+		return &cursorValue{Kind: "i", Value: strconv.FormatInt(value.Int(), 10)}
+	case value.CanUint():
+		return &cursorValue{Kind: "u", Value: strconv.FormatUint(value.Uint(), 10)}
+	case value.CanFloat():
+		return &cursorValue{Kind: "f", Value: strconv.FormatFloat(value.Float(), 'g', -1, 64)}
+	case value.Kind() == reflect.Bool:
+		return &cursorValue{Kind: "b", Value: strconv.FormatBool(value.Bool())}
+	case value.Kind() == reflect.String:
+		return &cursorValue{Kind: "s", Value: value.String()}
+	}
+	return &cursorValue{Kind: "s", Value: fmt.Sprint(v)}
+}
+
+func (this *cursorValue) decode() (interface{}, error) {
+	var v interface{}
+	var err error
+	switch this.Kind {
+	case "n":
+		return nil, nil
+	case "i":
+		v, err = strconv.ParseInt(this.Value, 10, 64)
+	case "u":
+		v, err = strconv.ParseUint(this.Value, 10, 64)
+	case "f":
+		v, err = strconv.ParseFloat(this.Value, 64)
+	case "b":
+		v, err = strconv.ParseBool(this.Value)
+	case "s":
+		v = this.Value
+	default:
+		err = errors.New("unknown kind " + this.Kind)
+	}
+	if err != nil {
+		return nil, errors.New("Invalid cursor value: " + err.Error())
+	}
+	return v, nil
+}
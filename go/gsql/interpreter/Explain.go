@@ -0,0 +1,332 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+)
+
+const (
+	eqSelectivity       = 0.1
+	wildcardSelectivity = 0.25
+	rangeSelectivity    = 0.33
+)
+
+// Plan is the query plan, it can be rendered as a tree with String or as JSON with JSON.
+type Plan struct {
+	Query       string     `json:"query"`
+	RootType    string     `json:"rootType"`
+	Columns     []string   `json:"columns"`
+	SortBy      string     `json:"sortBy,omitempty"`
+	Normalized  string     `json:"normalized"`
+	Index       string     `json:"index"`
+	Cost        float64    `json:"cost"`
+	Selectivity float64    `json:"selectivity"`
+	Where       *PlanNode  `json:"where,omitempty"`
+	Stats       *PlanStats `json:"stats,omitempty"`
+}
+
+// PlanNode is an expression, condition or comparator of the where clause.
+// The comparators are numbered by their evaluation order.
+type PlanNode struct {
+	Node           string       `json:"node"`
+	Operator       string       `json:"operator,omitempty"`
+	Order          int          `json:"order,omitempty"`
+	Left           *PlanOperand `json:"left,omitempty"`
+	Right          *PlanOperand `json:"right,omitempty"`
+	Implementation string       `json:"implementation,omitempty"`
+	Cost           float64      `json:"cost"`
+	Selectivity    float64      `json:"selectivity"`
+	Children       []*PlanNode  `json:"children,omitempty"`
+	Stats          *PlanStats   `json:"stats,omitempty"`
+}
+
+// PlanOperand is a comparator operand, a resolved property, a literal or a placeholder.
+type PlanOperand struct {
+	Text     string `json:"text"`
+	Property string `json:"property,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	Param    string `json:"param,omitempty"`
+}
+
+// IsExplain returns true if the query text had the explain prefix.
+func (this *Query) IsExplain() bool {
+	return this.explain
+}
+
+// Explain returns the plan of the query.
+func (this *Query) Explain() *Plan {
+	plan := &Plan{}
+	plan.Query = this.String()
+	plan.RootType = this.rootType.TypeName
+	plan.Columns = make([]string, 0, len(this.properties))
+	for i, column := range this.properties {
+		if this.paths[i] != nil {
+			plan.Columns = append(plan.Columns, this.paths[i].String())
+			continue
+		}
+		pid, _ := column.PropertyId()
+		plan.Columns = append(plan.Columns, pid)
+	}
+	sortBy := make([]string, 0, len(this.sortKeys))
+	for _, key := range this.sortKeys {
+		sortBy = append(sortBy, key.String())
+	}
+	plan.SortBy = strings.Join(sortBy, ",")
+	plan.Index = "none"
+	plan.Selectivity = 1
+	if this.where != nil {
+		plan.Normalized = this.where.normalize().canonical()
+		order := 0
+		plan.Where = this.where.plan(this, &order)
+		plan.Cost = plan.Where.Cost
+		plan.Selectivity = plan.Where.Selectivity
+	}
+	return plan
+}
+
+func (this *Expression) plan(query *Query, order *int) *PlanNode {
+	node := &PlanNode{Node: "expression", Operator: strings.TrimSpace(string(this.operation))}
+	if this.condition != nil {
+		node.Children = append(node.Children, this.condition.plan(query, order))
+	}
+	if this.child != nil {
+		node.Children = append(node.Children, this.child.plan(query, order))
+	}
+	if this.next != nil {
+		node.Children = append(node.Children, this.next.plan(query, order))
+	}
+	node.Cost = combineCost(this.operation, node.Children)
+	node.Selectivity = combineSelectivity(this.operation, node.Children)
+	return node
+}
+
+func (this *Condition) plan(query *Query, order *int) *PlanNode {
+	node := &PlanNode{Node: "condition", Operator: strings.TrimSpace(string(this.operation))}
+	if this.comparator != nil {
+		node.Children = append(node.Children, this.comparator.plan(query, order))
+	}
+	if this.next != nil {
+		node.Children = append(node.Children, this.next.plan(query, order))
+	}
+	node.Cost = combineCost(this.operation, node.Children)
+	node.Selectivity = combineSelectivity(this.operation, node.Children)
+	return node
+}
+
+func (this *Comparator) plan(query *Query, order *int) *PlanNode {
+	*order++
+	node := &PlanNode{Node: "comparator", Operator: strings.TrimSpace(string(this.operation)), Order: *order}
+	node.Left = planOperand(this.left, this.leftProperty, this.leftPath, this.leftParam, query)
+	node.Right = planOperand(this.right, this.rightProperty, this.rightPath, this.rightParam, query)
+	node.Implementation = this.implementation(query)
+	node.Cost = this.cost()
+	node.Selectivity = this.selectivity()
+	return node
+}
+
+func planOperand(value string, property *properties.Property, path *accessPath, param string, query *Query) *PlanOperand {
+	operand := &PlanOperand{Text: value, Param: param}
+	if property != nil {
+		operand.Property, _ = property.PropertyId()
+		if path != nil {
+			operand.Property = path.String()
+		}
+		if node := property.Node(); node != nil {
+			operand.Kind = kindOf(node, query.resources).String()
+		}
+	}
+	return operand
+}
+
+// implementation returns the comparator implementation and the kind it compares,
+// the kind of the property or string when both sides are literals.
+func (this *Comparator) implementation(query *Query) string {
+	matcher := comparables[this.operation]
+	if matcher == nil {
+		return "none"
+	}
+	kind := reflect.String
+	for _, prop := range []*properties.Property{this.leftProperty, this.rightProperty} {
+		if prop != nil && prop.Node() != nil {
+			if k := kindOf(prop.Node(), query.resources); k != reflect.String {
+				kind = k
+				break
+			}
+		}
+	}
+	return reflect.TypeOf(matcher).Elem().Name() + "/" + kind.String()
+}
+
+// selectivity estimates the fraction of the elements the comparator matches, without statistics
+// an equality is assumed to match 10% of the elements and a range a third of the elements.
+func (this *Comparator) selectivity() float64 {
+	literal := this.right
+	if this.rightProperty != nil || this.rightParam != "" {
+		literal = this.left
+	}
+	literal = strings.TrimSpace(literal)
+	switch this.operation {
+	case parser.Eq:
+		return literalSelectivity(literal)
+	case parser.Neq:
+		return 1 - literalSelectivity(literal)
+	case parser.IN:
+		return listSelectivity(literal)
+	case parser.NOTIN:
+		return 1 - listSelectivity(literal)
+	}
+	return rangeSelectivity
+}
+
+func literalSelectivity(literal string) float64 {
+	if literal == "*" {
+		return 1
+	}
+	if strings.Contains(literal, "*") {
+		return wildcardSelectivity
+	}
+	return eqSelectivity
+}
+
+func listSelectivity(literal string) float64 {
+	items := 1
+	if strings.HasPrefix(literal, "[") && strings.HasSuffix(literal, "]") {
+		items = len(strings.Split(literal[1:len(literal)-1], ","))
+	}
+	selectivity := float64(items) * eqSelectivity
+	if selectivity > 1 {
+		return 1
+	}
+	return selectivity
+}
+
+// combineSelectivity assumes the operands are independent.
+func combineSelectivity(operation parser.ConditionOperation, children []*PlanNode) float64 {
+	result := 1.0
+	if operation == parser.Or {
+		for _, child := range children {
+			result *= 1 - child.Selectivity
+		}
+		return 1 - result
+	}
+	for _, child := range children {
+		result *= child.Selectivity
+	}
+	return result
+}
+
+// JSON returns the plan as indented JSON.
+func (this *Plan) JSON() ([]byte, error) {
+	return json.MarshalIndent(this, "", "  ")
+}
+
+// String returns the plan as a human readable tree.
+func (this *Plan) String() string {
+	buff := &bytes.Buffer{}
+	buff.WriteString("Query: ")
+	buff.WriteString(this.Query)
+	buff.WriteString("\nRoot Type: ")
+	buff.WriteString(this.RootType)
+	buff.WriteString("\nColumns: ")
+	if len(this.Columns) == 0 {
+		buff.WriteString("*")
+	} else {
+		buff.WriteString(strings.Join(this.Columns, ","))
+	}
+	if this.SortBy != "" {
+		buff.WriteString("\nSort By: ")
+		buff.WriteString(this.SortBy)
+	}
+	buff.WriteString("\nNormalized: ")
+	buff.WriteString(this.Normalized)
+	buff.WriteString("\nIndex: ")
+	buff.WriteString(this.Index)
+	buff.WriteString("\nCost: ")
+	buff.WriteString(formatCost(this.Cost))
+	buff.WriteString("\nSelectivity: ")
+	buff.WriteString(formatSelectivity(this.Selectivity))
+	buff.WriteString("\n")
+	if this.Stats != nil {
+		buff.WriteString("Analyzed: ")
+		buff.WriteString(this.Stats.String())
+		buff.WriteString("\n")
+	}
+	if this.Where != nil {
+		buff.WriteString("Where:\n")
+		this.Where.write(buff, "", "")
+	}
+	return buff.String()
+}
+
+func (this *PlanNode) write(buff *bytes.Buffer, prefix, childPrefix string) {
+	buff.WriteString(prefix)
+	buff.WriteString(this.title())
+	buff.WriteString("\n")
+	for i, child := range this.Children {
+		if i == len(this.Children)-1 {
+			child.write(buff, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			child.write(buff, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+func (this *PlanNode) title() string {
+	buff := bytes.Buffer{}
+	if this.Node == "comparator" {
+		buff.WriteString("#")
+		buff.WriteString(strconv.Itoa(this.Order))
+		buff.WriteString(" ")
+		buff.WriteString(this.Left.String())
+		buff.WriteString(" ")
+		buff.WriteString(this.Operator)
+		buff.WriteString(" ")
+		buff.WriteString(this.Right.String())
+		buff.WriteString(" using ")
+		buff.WriteString(this.Implementation)
+	} else {
+		buff.WriteString(this.Node)
+		if this.Operator != "" {
+			buff.WriteString(" ")
+			buff.WriteString(this.Operator)
+		}
+	}
+	buff.WriteString(" (cost ")
+	buff.WriteString(formatCost(this.Cost))
+	buff.WriteString(", selectivity ")
+	buff.WriteString(formatSelectivity(this.Selectivity))
+	buff.WriteString(")")
+	if this.Stats != nil {
+		buff.WriteString(" [")
+		buff.WriteString(this.Stats.String())
+		buff.WriteString("]")
+	}
+	return buff.String()
+}
+
+func (this *PlanOperand) String() string {
+	if this.Param != "" {
+		if _, err := strconv.Atoi(this.Param); err == nil {
+			return parser.NumberedParam + this.Param
+		}
+		return parser.NamedParam + this.Param
+	}
+	if this.Property != "" {
+		return this.Property
+	}
+	return this.Text
+}
+
+func formatCost(cost float64) string {
+	return strconv.FormatFloat(cost, 'f', 2, 64)
+}
+
+func formatSelectivity(selectivity float64) string {
+	return strconv.FormatFloat(selectivity, 'f', 4, 64)
+}
@@ -2,7 +2,9 @@ package interpreter
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"time"
 
 	"github.com/saichler/l8ql/go/gsql/parser"
 	"github.com/saichler/l8types/go/ifs"
@@ -37,14 +39,14 @@ func (this *Expression) String() string {
 	return buff.String()
 }
 
-func CreateExpression(expr *l8api.L8Expression, rootTable *l8reflect.L8Node, resources ifs.IResources) (*Expression, error) {
+func CreateExpression(expr *l8api.L8Expression, rootTable *l8reflect.L8Node, resources ifs.IResources, opts ...Option) (*Expression, error) {
 	if expr == nil {
 		return nil, nil
 	}
 	ormExpr := &Expression{}
 	ormExpr.operation = parser.ConditionOperation(expr.AndOr)
 	if expr.Condition != nil {
-		cond, e := CreateCondition(expr.Condition, rootTable, resources)
+		cond, e := CreateCondition(expr.Condition, rootTable, resources, opts...)
 		if e != nil {
 			return nil, e
 		}
@@ -52,7 +54,7 @@ func CreateExpression(expr *l8api.L8Expression, rootTable *l8reflect.L8Node, res
 	}
 
 	if expr.Child != nil {
-		child, e := CreateExpression(expr.Child, rootTable, resources)
+		child, e := CreateExpression(expr.Child, rootTable, resources, opts...)
 		if e != nil {
 			return nil, e
 		}
@@ -60,7 +62,7 @@ func CreateExpression(expr *l8api.L8Expression, rootTable *l8reflect.L8Node, res
 	}
 
 	if expr.Next != nil {
-		next, e := CreateExpression(expr.Next, rootTable, resources)
+		next, e := CreateExpression(expr.Next, rootTable, resources, opts...)
 		if e != nil {
 			return nil, e
 		}
@@ -70,7 +72,25 @@ func CreateExpression(expr *l8api.L8Expression, rootTable *l8reflect.L8Node, res
 	return ormExpr, nil
 }
 
+// Match evaluates the expression in order and short circuits, i.e. an and stops at the first false
+// operand and an or stops at the first true operand, so errors of the skipped operands are not returned.
 func (this *Expression) Match(root interface{}) (bool, error) {
+	return this.match(context.Background(), root, nil)
+}
+
+// shortCircuits returns true if the value of an operand decides the value of the operation.
+func shortCircuits(operation parser.ConditionOperation, value bool) bool {
+	return (operation == parser.And || operation == "") && !value || operation == parser.Or && value
+}
+
+// match evaluates the expression until the context is done, recording the evaluation statistics in the plan node if it is not nil.
+func (this *Expression) match(ctx context.Context, root interface{}, node *PlanNode) (result bool, err error) {
+	if node != nil {
+		defer node.record(time.Now(), &result, &err)
+	}
+	if err = ctx.Err(); err != nil {
+		return false, err
+	}
 	cond := true
 	child := true
 	next := true
@@ -80,20 +100,29 @@ func (this *Expression) Match(root interface{}) (bool, error) {
 		child = false
 		next = false
 	}
+	index := 0
 	if this.condition != nil {
-		cond, e = this.condition.Match(root)
+		cond, e = this.condition.match(ctx, root, node.child(index))
 		if e != nil {
 			return false, e
 		}
+		if shortCircuits(this.operation, cond) {
+			return cond, nil
+		}
+		index++
 	}
 	if this.child != nil {
-		child, e = this.child.Match(root)
+		child, e = this.child.match(ctx, root, node.child(index))
 		if e != nil {
 			return false, e
 		}
+		if shortCircuits(this.operation, child) {
+			return child, nil
+		}
+		index++
 	}
 	if this.next != nil {
-		next, e = this.next.Match(root)
+		next, e = this.next.match(ctx, root, node.child(index))
 		if e != nil {
 			return false, e
 		}
@@ -126,16 +155,3 @@ func (this *Expression) Next() ifs.IExpression {
 func (this *Expression) Child() ifs.IExpression {
 	return this.child
 }
-
-func (this *Expression) keyOf() string {
-	if this.condition != nil {
-		return this.condition.keyOf()
-	}
-	if this.child != nil {
-		return this.child.keyOf()
-	}
-	if this.next != nil {
-		return this.next.keyOf()
-	}
-	return ""
-}
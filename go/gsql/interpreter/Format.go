@@ -0,0 +1,63 @@
+package interpreter
+
+import (
+	"strings"
+
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+// ToL8Query returns the query as built from the interpreter tree, so unlike Query(),
+// it includes the values of a bound query.
+func (this *Query) ToL8Query() *l8api.L8Query {
+	query := &l8api.L8Query{}
+	if this.rootType != nil {
+		query.RootType = strings.ToLower(this.rootType.TypeName)
+	}
+	if this.query != nil {
+		query.Properties = append(query.Properties, this.query.Properties...)
+	}
+	if this.where != nil {
+		query.Criteria = this.where.toL8()
+	}
+	query.SortBy = this.sortBy
+	query.Descending = this.descending
+	query.Limit = this.limit
+	query.Page = this.page
+	query.MatchCase = this.matchCase
+	return query
+}
+
+func (this *Expression) toL8() *l8api.L8Expression {
+	expr := &l8api.L8Expression{}
+	expr.AndOr = string(this.operation)
+	if this.condition != nil {
+		expr.Condition = this.condition.toL8()
+	}
+	if this.child != nil {
+		expr.Child = this.child.toL8()
+	}
+	if this.next != nil {
+		expr.Next = this.next.toL8()
+	}
+	return expr
+}
+
+func (this *Condition) toL8() *l8api.L8Condition {
+	cond := &l8api.L8Condition{}
+	cond.Oper = string(this.operation)
+	if this.comparator != nil {
+		cond.Comparator = this.comparator.toL8()
+	}
+	if this.next != nil {
+		cond.Next = this.next.toL8()
+	}
+	return cond
+}
+
+func (this *Comparator) toL8() *l8api.L8Comparator {
+	cmp := &l8api.L8Comparator{}
+	cmp.Left = this.left
+	cmp.Oper = string(this.operation)
+	cmp.Right = this.right
+	return cmp
+}
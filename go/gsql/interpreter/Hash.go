@@ -0,0 +1,138 @@
+package interpreter
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+)
+
+type HashVersion int
+
+const (
+	// HashV1 is the legacy hash of the root type, the where clause & the resolved sort keys.
+	HashV1 HashVersion = 1
+	// HashV2 is the MD5 of the canonical form of the query.
+	HashV2 HashVersion = 2
+	// HashV3 is the SHA-256 of the canonical form of the query.
+	HashV3               HashVersion = 3
+	DEFAULT_HASH_VERSION             = HashV2
+	// CANONICAL_VERSION is bumped whenever the canonical form changes.
+	CANONICAL_VERSION = 3
+)
+
+// HashOf returns the hex hash of the query with the given hash version.
+func (this *Query) HashOf(version HashVersion) string {
+	var h hash.Hash
+	switch version {
+	case HashV1:
+		return this.legacyHash()
+	case HashV3:
+		h = sha256.New()
+	default:
+		h = md5.New()
+	}
+	h.Write([]byte(this.Canonical()))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Canonical returns a normalized form covering all the query attributes. Logically identical queries,
+// e.g. queries that differ in the order of and/or operands, in the order of the selected columns or in
+// the quoting & case of literals, have the same canonical form.
+func (this *Query) Canonical() string {
+	buff := bytes.Buffer{}
+	buff.WriteString("v")
+	buff.WriteString(strconv.Itoa(CANONICAL_VERSION))
+	buff.WriteString("|from=")
+	if this.rootType != nil {
+		buff.WriteString(strings.ToLower(this.rootType.TypeName))
+	}
+	buff.WriteString("|select=")
+	columns := make([]string, 0, len(this.properties))
+	seen := make(map[string]bool)
+	for i, column := range this.properties {
+		var id string
+		if this.paths[i] != nil {
+			id = this.paths[i].String()
+		} else {
+			id, _ = column.PropertyId()
+		}
+		id = strings.ToLower(id)
+		if !seen[id] {
+			seen[id] = true
+			columns = append(columns, id)
+		}
+	}
+	sort.Strings(columns)
+	buff.WriteString(strings.Join(columns, ","))
+	buff.WriteString("|where=")
+	if this.where != nil {
+		buff.WriteString(this.where.normalize().canonical())
+	}
+	buff.WriteString("|sort-by=")
+	buff.WriteString(this.sortByCanonical())
+	buff.WriteString("|descending=")
+	buff.WriteString(strconv.FormatBool(this.descending))
+	buff.WriteString("|limit=")
+	buff.WriteString(strconv.Itoa(int(this.limit)))
+	buff.WriteString("|page=")
+	buff.WriteString(strconv.Itoa(int(this.page)))
+	buff.WriteString("|match-case=")
+	buff.WriteString(strconv.FormatBool(this.matchCase))
+	buff.WriteString("|after=")
+	buff.WriteString(this.afterCanonical())
+	return buff.String()
+}
+
+func (this *Query) legacyHash() string {
+	buff := bytes.Buffer{}
+	if this.rootType != nil {
+		buff.WriteString(this.rootType.TypeName)
+	}
+	if this.where != nil {
+		buff.WriteString(this.where.String())
+	}
+	buff.WriteString(this.sortByCanonical())
+	h := md5.New()
+	h.Write(buff.Bytes())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sortByCanonical returns the resolved sort keys, each with its property id, its direction, the position
+// of its nulls and its collation, so equivalent sort-by clauses, e.g. "mystring" and "testproto.mystring asc",
+// have the same form.
+func (this *Query) sortByCanonical() string {
+	keys := make([]string, 0, len(this.sortKeys))
+	for _, resolved := range this.sortKeys {
+		key := *resolved.key
+		if resolved.path != nil {
+			key.Property = resolved.path.String()
+		} else {
+			id, _ := resolved.property.PropertyId()
+			key.Property = strings.ToLower(id)
+		}
+		if !key.HasDirection {
+			key.HasDirection, key.Descending = true, this.descending
+		}
+		if key.Nulls == parser.NullsDefault {
+			key.Nulls = parser.NullsFirst
+			if key.Descending {
+				key.Nulls = parser.NullsLast
+			}
+		}
+		if key.Collation == parser.CollationDefault {
+			key.Collation = parser.CollationNoCase
+			if this.matchCase {
+				key.Collation = parser.CollationBinary
+			}
+		}
+		keys = append(keys, key.String())
+	}
+	return strings.Join(keys, ",")
+}
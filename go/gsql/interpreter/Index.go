@@ -0,0 +1,279 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter/comparators"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// IndexKind is the kind of a secondary index.
+type IndexKind int
+
+const (
+	// HashIndex answers = and in.
+	HashIndex IndexKind = iota
+	// OrderedIndex answers =, in and the ranges, >, >=, < and <=.
+	OrderedIndex
+)
+
+func (this IndexKind) String() string {
+	if this == OrderedIndex {
+		return "ordered"
+	}
+	return "hash"
+}
+
+// entry is an element of an indexed collection, the sequence is the insertion order of its key.
+type entry struct {
+	key     string
+	elem    interface{}
+	seq     int64
+	deleted bool
+}
+
+// index is a secondary index of a scalar property. The key of a value is the value as compared by the
+// comparators, strings are in lower case. An element whose value has no key, or is the * wildcard that
+// equals any string, is kept apart and is always a candidate, so a lookup never misses an element that matches.
+type index struct {
+	kind     IndexKind
+	id       string
+	property *properties.Property
+	valueOf  reflect.Kind
+	hash     map[interface{}]map[*entry]bool
+	ordered  *skiplist
+	keys     map[*entry]interface{}
+	unkeyed  map[*entry]bool
+}
+
+func newIndex(kind IndexKind, path, rootType string, resources ifs.IResources) (*index, error) {
+	property, err := properties.PropertyOf(propertyPath(path, rootType), resources)
+	if err != nil {
+		return nil, err
+	}
+	node := property.Node()
+	if node == nil || !isScalar(node) {
+		return nil, errors.New("Cannot index " + path + ", only scalar properties can be indexed")
+	}
+	valueOf := kindOf(node, resources)
+	switch {
+	case valueOf == reflect.String, isIntKind(valueOf), isUintKind(valueOf):
+	case valueOf == reflect.Bool && kind == HashIndex:
+	default:
+		return nil, errors.New("Cannot create a " + kind.String() + " index of " + path + " of kind " + valueOf.String())
+	}
+	result := &index{kind: kind, property: property, valueOf: valueOf}
+	result.id, _ = property.PropertyId()
+	result.keys = make(map[*entry]interface{})
+	result.unkeyed = make(map[*entry]bool)
+	if kind == HashIndex {
+		result.hash = make(map[interface{}]map[*entry]bool)
+	} else {
+		result.ordered = newSkiplist()
+	}
+	return result, nil
+}
+
+func (this *index) insert(e *entry) {
+	value, err := this.property.Get(e.elem)
+	key, ok := this.keyOf(value)
+	if err != nil || !ok || key == "*" {
+		this.unkeyed[e] = true
+		return
+	}
+	this.keys[e] = key
+	if this.hash != nil {
+		set, ok := this.hash[key]
+		if !ok {
+			set = make(map[*entry]bool)
+			this.hash[key] = set
+		}
+		set[e] = true
+	} else {
+		this.ordered.insert(key, e)
+	}
+}
+
+// clone returns a copy of the index over the copies of the entries.
+func (this *index) clone(copies map[*entry]*entry) *index {
+	result := &index{kind: this.kind, id: this.id, property: this.property, valueOf: this.valueOf}
+	result.keys = make(map[*entry]interface{}, len(this.keys))
+	result.unkeyed = make(map[*entry]bool, len(this.unkeyed))
+	for e := range this.unkeyed {
+		result.unkeyed[copies[e]] = true
+	}
+	if this.hash != nil {
+		result.hash = make(map[interface{}]map[*entry]bool, len(this.hash))
+		for key, set := range this.hash {
+			copied := make(map[*entry]bool, len(set))
+			for e := range set {
+				copied[copies[e]] = true
+				result.keys[copies[e]] = key
+			}
+			result.hash[key] = copied
+		}
+		return result
+	}
+	result.ordered = newSkiplist()
+	this.ordered.scan(nil, false, nil, false, func(e *entry) {
+		key := this.keys[e]
+		result.keys[copies[e]] = key
+		result.ordered.insert(key, copies[e])
+	})
+	return result
+}
+
+func (this *index) remove(e *entry) {
+	if this.unkeyed[e] {
+		delete(this.unkeyed, e)
+		return
+	}
+	key, ok := this.keys[e]
+	if !ok {
+		return
+	}
+	delete(this.keys, e)
+	if this.hash != nil {
+		set := this.hash[key]
+		delete(set, e)
+		if len(set) == 0 {
+			delete(this.hash, key)
+		}
+	} else {
+		this.ordered.remove(key, e)
+	}
+}
+
+// keyOf returns the key of a value of the property.
+func (this *index) keyOf(value interface{}) (interface{}, bool) {
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Kind() == reflect.String:
+		return comparators.UnquoteValue(strings.ToLower(v.String())), true
+	case v.CanInt():
+		return v.Int(), true
+	case v.CanUint():
+		return v.Uint(), true
+	case v.Kind() == reflect.Bool:
+		return v.Bool(), true
+	}
+	return nil, false
+}
+
+// literalKey returns the key of a literal the property is compared to, false if the literal is not
+// compared by its key, e.g. a wildcard or nil, which are answered by a scan.
+func (this *index) literalKey(literal string, operation parser.ComparatorOperation) (interface{}, bool) {
+	lower := strings.ToLower(literal)
+	switch {
+	case this.valueOf == reflect.String:
+		value := comparators.Unquote(lower)
+		if operation == parser.Eq && (value == "" || comparators.IsNil(lower) || comparators.GetWildCardSubstrings(lower) != nil) {
+			return nil, false
+		}
+		return value, true
+	case isIntKind(this.valueOf):
+		i, err := strconv.Atoi(literal)
+		return int64(i), err == nil
+	case isUintKind(this.valueOf):
+		i, err := strconv.Atoi(literal)
+		return uint64(i), err == nil && i >= 0
+	case this.valueOf == reflect.Bool:
+		b, err := strconv.ParseBool(comparators.Unquote(lower))
+		return b, err == nil
+	}
+	return nil, false
+}
+
+// lookup returns the candidates of the comparator, false if the index does not answer the comparator.
+func (this *index) lookup(c *Comparator) (map[*entry]bool, bool) {
+	switch c.operation {
+	case parser.Eq:
+		key, ok := this.literalKey(c.right, c.operation)
+		if !ok {
+			return nil, false
+		}
+		result := this.candidates()
+		this.collect(key, result)
+		return result, true
+	case parser.IN:
+		items, ok := inItems(strings.ToLower(c.right))
+		if !ok {
+			return nil, false
+		}
+		result := this.candidates()
+		for _, item := range items {
+			// as the in comparator, the items after an item that is not a number are ignored
+			if _, err := strconv.Atoi(item); err != nil && this.valueOf != reflect.String {
+				break
+			}
+			if key, ok := this.literalKey(item, parser.IN); ok {
+				this.collect(key, result)
+			}
+		}
+		return result, true
+	case parser.GT, parser.GTEQ, parser.LT, parser.LTEQ:
+		if this.ordered == nil {
+			return nil, false
+		}
+		key, ok := this.literalKey(c.right, c.operation)
+		if !ok {
+			return nil, false
+		}
+		result := this.candidates()
+		add := func(e *entry) { result[e] = true }
+		switch c.operation {
+		case parser.GT, parser.GTEQ:
+			this.ordered.scan(key, c.operation == parser.GTEQ, nil, false, add)
+		default:
+			this.ordered.scan(nil, false, key, c.operation == parser.LTEQ, add)
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+// candidates returns a new candidates set with the elements that have no key.
+func (this *index) candidates() map[*entry]bool {
+	result := make(map[*entry]bool, len(this.unkeyed))
+	for e := range this.unkeyed {
+		result[e] = true
+	}
+	return result
+}
+
+func (this *index) collect(key interface{}, result map[*entry]bool) {
+	if this.hash != nil {
+		for e := range this.hash[key] {
+			result[e] = true
+		}
+		return
+	}
+	this.ordered.scan(key, true, key, true, func(e *entry) { result[e] = true })
+}
+
+// inItems returns the items of an in list literal, e.g. [1,2,3].
+func inItems(literal string) ([]string, bool) {
+	index := strings.Index(literal, "[")
+	index2 := strings.Index(literal, "]")
+	if index == -1 || index2 <= index {
+		return nil, false
+	}
+	items := strings.Split(literal[index+1:index2], ",")
+	for i, item := range items {
+		items[i] = comparators.Unquote(strings.TrimSpace(item))
+	}
+	return items, true
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uint64
+}
@@ -0,0 +1,351 @@
+package interpreter
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter/comparators"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// IndexedCollection is a collection of elements of a root type keyed by a key, with secondary indexes
+// on scalar properties of the elements. Filter answers the =, in and range comparators of the where
+// clause from the indexes, intersecting the candidates of and operands & uniting the candidates of
+// or operands, and matches only the candidates instead of scanning the collection.
+type IndexedCollection struct {
+	mtx       sync.RWMutex
+	rootType  string
+	resources ifs.IResources
+	entries   map[string]*entry
+	order     []*entry
+	deleted   int
+	seq       int64
+	indexes   map[string][]*index
+	primary   bool
+	folded    map[string]map[*entry]bool
+}
+
+func NewIndexedCollection(rootType string, resources ifs.IResources) *IndexedCollection {
+	collection := &IndexedCollection{}
+	collection.rootType = rootType
+	collection.resources = resources
+	collection.entries = make(map[string]*entry)
+	collection.order = make([]*entry, 0)
+	collection.indexes = make(map[string][]*index)
+	collection.folded = make(map[string]map[*entry]bool)
+	return collection
+}
+
+// AddIndex adds an index of the kind on the property path, e.g. "myint32", and indexes the elements.
+func (this *IndexedCollection) AddIndex(path string, kind IndexKind) error {
+	idx, err := newIndex(kind, path, this.rootType, this.resources)
+	if err != nil {
+		return err
+	}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	for _, existing := range this.indexes[idx.id] {
+		if existing.kind == kind {
+			return errors.New("A " + kind.String() + " index of " + idx.id + " already exists")
+		}
+	}
+	for _, e := range this.order {
+		if !e.deleted {
+			idx.insert(e)
+		}
+	}
+	indexes := append(this.indexes[idx.id], idx)
+	// the hash index is preferred for = & in, the ordered index answers the ranges
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i].kind < indexes[j].kind
+	})
+	this.indexes[idx.id] = indexes
+	return nil
+}
+
+// Put inserts the element with the key, or updates the element of the key, in place in the collection order.
+func (this *IndexedCollection) Put(key string, elem interface{}) {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	e, ok := this.entries[key]
+	if ok {
+		this.unindex(e)
+		e.elem = elem
+		this.index(e)
+		return
+	}
+	this.seq++
+	e = &entry{key: key, elem: elem, seq: this.seq}
+	this.entries[key] = e
+	this.order = append(this.order, e)
+	this.fold(e)
+	this.index(e)
+}
+
+// Delete removes the element of the key and returns true if there was one.
+func (this *IndexedCollection) Delete(key string) bool {
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	e, ok := this.entries[key]
+	if !ok {
+		return false
+	}
+	this.unindex(e)
+	this.unfold(e)
+	delete(this.entries, key)
+	e.deleted = true
+	this.deleted++
+	if this.deleted > len(this.order)/2 {
+		this.compact()
+	}
+	return true
+}
+
+// Get returns the element of the key.
+func (this *IndexedCollection) Get(key string) (interface{}, bool) {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	e, ok := this.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return e.elem, true
+}
+
+// Len returns the number of elements.
+func (this *IndexedCollection) Len() int {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return len(this.entries)
+}
+
+// Filter returns the elements that match the query in the collection order, as Query.Filter of the
+// collection, with the candidates answered by the indexes.
+func (this *IndexedCollection) Filter(query *Query, onlySelectedColumns bool) []interface{} {
+	this.mtx.RLock()
+	list, _ := this.candidates(query)
+	this.mtx.RUnlock()
+	return query.Filter(list, onlySelectedColumns)
+}
+
+// Explain returns the plan of the query, with the index usage of the collection.
+func (this *IndexedCollection) Explain(query *Query) *Plan {
+	this.mtx.RLock()
+	_, used := this.candidates(query)
+	this.mtx.RUnlock()
+	plan := query.Explain()
+	if used != "" {
+		plan.Index = used
+	}
+	return plan
+}
+
+// candidates returns the elements that may match the query, in the collection order,
+// and the usage of the indexes, empty if the whole collection is scanned.
+func (this *IndexedCollection) candidates(query *Query) ([]interface{}, string) {
+	var set map[*entry]bool
+	used := ""
+	keys, ok := []string(nil), false
+	if this.primary {
+		keys, ok = query.PrimaryKeys()
+	}
+	if ok {
+		set, used = this.lookupKeys(keys), "primary("+strings.Join(keys, ",")+")"
+	} else if query.where != nil {
+		set, used = this.lookup(query.where.normalize())
+	}
+	if set == nil {
+		list := make([]interface{}, 0, len(this.entries))
+		for _, e := range this.order {
+			if !e.deleted {
+				list = append(list, e.elem)
+			}
+		}
+		return list, ""
+	}
+	entries := make([]*entry, 0, len(set))
+	for e := range set {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+	list := make([]interface{}, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e.elem)
+	}
+	return list, used
+}
+
+// lookup returns the candidates of the node and the usage of the indexes, a nil set if the node
+// is not answered by the indexes and all the elements are candidates.
+func (this *IndexedCollection) lookup(node *normalNode) (map[*entry]bool, string) {
+	switch {
+	case node.constant:
+		if node.value {
+			return nil, ""
+		}
+		return make(map[*entry]bool), "none(false)"
+	case node.comparator != nil:
+		return this.lookupComparator(node.comparator)
+	}
+	sets := make([]map[*entry]bool, 0, len(node.children))
+	used := make([]string, 0, len(node.children))
+	for _, child := range node.children {
+		set, u := this.lookup(child)
+		if set == nil {
+			if node.operation == parser.Or {
+				return nil, ""
+			}
+			continue
+		}
+		sets = append(sets, set)
+		used = append(used, u)
+	}
+	if len(sets) == 0 {
+		return nil, ""
+	}
+	operation := strings.TrimSpace(string(node.operation))
+	usage := used[0]
+	if len(used) > 1 {
+		usage = operation + "(" + strings.Join(used, ",") + ")"
+	}
+	if node.operation == parser.Or {
+		return union(sets), usage
+	}
+	return intersect(sets), usage
+}
+
+func (this *IndexedCollection) lookupComparator(c *Comparator) (map[*entry]bool, string) {
+	if c.leftProperty == nil || c.leftPath != nil || c.rightProperty != nil || c.rightPath != nil ||
+		c.leftParam != "" || c.rightParam != "" {
+		return nil, ""
+	}
+	id, _ := c.leftProperty.PropertyId()
+	for _, idx := range this.indexes[id] {
+		if set, ok := idx.lookup(c); ok {
+			return set, idx.kind.String() + "(" + c.canonical() + ")"
+		}
+	}
+	return nil, ""
+}
+
+func (this *IndexedCollection) index(e *entry) {
+	for _, indexes := range this.indexes {
+		for _, idx := range indexes {
+			idx.insert(e)
+		}
+	}
+}
+
+func (this *IndexedCollection) unindex(e *entry) {
+	for _, indexes := range this.indexes {
+		for _, idx := range indexes {
+			idx.remove(e)
+		}
+	}
+}
+
+// clone returns a copy of the collection, the elements are shared.
+func (this *IndexedCollection) clone() *IndexedCollection {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	collection := NewIndexedCollection(this.rootType, this.resources)
+	collection.seq = this.seq
+	collection.primary = this.primary
+	copies := make(map[*entry]*entry, len(this.entries))
+	collection.order = make([]*entry, 0, len(this.entries))
+	for _, e := range this.order {
+		if !e.deleted {
+			copied := *e
+			copies[e] = &copied
+			collection.entries[e.key] = &copied
+			collection.order = append(collection.order, &copied)
+			collection.fold(&copied)
+		}
+	}
+	for id, indexes := range this.indexes {
+		for _, idx := range indexes {
+			collection.indexes[id] = append(collection.indexes[id], idx.clone(copies))
+		}
+	}
+	return collection
+}
+
+// fold adds the entry to the keys in lower case, without quotes, as the primary keys of a query.
+func (this *IndexedCollection) fold(e *entry) {
+	key := comparators.UnquoteValue(strings.ToLower(e.key))
+	set, ok := this.folded[key]
+	if !ok {
+		set = make(map[*entry]bool)
+		this.folded[key] = set
+	}
+	set[e] = true
+}
+
+func (this *IndexedCollection) unfold(e *entry) {
+	key := comparators.UnquoteValue(strings.ToLower(e.key))
+	set := this.folded[key]
+	delete(set, e)
+	if len(set) == 0 {
+		delete(this.folded, key)
+	}
+}
+
+// lookupKeys returns the entries of the primary keys of a query, with the entries whose key is
+// the * wildcard, which equals any key.
+func (this *IndexedCollection) lookupKeys(keys []string) map[*entry]bool {
+	result := make(map[*entry]bool)
+	all := append(append(make([]string, 0, len(keys)+1), keys...), "*")
+	for _, key := range all {
+		for e := range this.folded[key] {
+			result[e] = true
+		}
+	}
+	return result
+}
+
+// compact removes the deleted entries from the collection order.
+func (this *IndexedCollection) compact() {
+	order := make([]*entry, 0, len(this.entries))
+	for _, e := range this.order {
+		if !e.deleted {
+			order = append(order, e)
+		}
+	}
+	this.order = order
+	this.deleted = 0
+}
+
+func intersect(sets []map[*entry]bool) map[*entry]bool {
+	sort.Slice(sets, func(i, j int) bool {
+		return len(sets[i]) < len(sets[j])
+	})
+	result := make(map[*entry]bool, len(sets[0]))
+	for e := range sets[0] {
+		in := true
+		for _, set := range sets[1:] {
+			if !set[e] {
+				in = false
+				break
+			}
+		}
+		if in {
+			result[e] = true
+		}
+	}
+	return result
+}
+
+func union(sets []map[*entry]bool) map[*entry]bool {
+	result := make(map[*entry]bool)
+	for _, set := range sets {
+		for e := range set {
+			result[e] = true
+		}
+	}
+	return result
+}
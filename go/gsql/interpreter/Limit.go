@@ -0,0 +1,74 @@
+package interpreter
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// Overflow is the behaviour of a LimitPolicy when the limit of a query is above the max limit.
+type Overflow int
+
+const (
+	// OverflowError fails the query.
+	OverflowError Overflow = iota
+	// OverflowClamp lowers the limit of the query to the max limit.
+	OverflowClamp
+)
+
+// LimitPolicy governs the limit of the queries, a zero limit means no limit.
+type LimitPolicy struct {
+	// DefaultLimit is the limit of a query with no limit.
+	DefaultLimit int32
+	// MaxLimit is the highest limit of a query, zero if there is no max limit.
+	MaxLimit int32
+	// Overflow is the behaviour when the limit of a query is above MaxLimit.
+	Overflow Overflow
+}
+
+// LimitPolicyProvider is implemented by resources that provide the limit policy of their queries,
+// the Limits option takes precedence.
+type LimitPolicyProvider interface {
+	LimitPolicy() *LimitPolicy
+}
+
+// Limits sets the limit policy of the query.
+func Limits(policy *LimitPolicy) Option {
+	return func(opts *options) {
+		opts.limits = policy
+	}
+}
+
+func limitPolicyOf(opts *options, resources ifs.IResources) *LimitPolicy {
+	if opts.limits != nil {
+		return opts.limits
+	}
+	if provider, ok := resources.(LimitPolicyProvider); ok {
+		return provider.LimitPolicy()
+	}
+	return nil
+}
+
+// Apply returns the limit of a query with the limit by the policy: the default limit if the limit
+// is zero, and the max limit if the limit is above it and the policy clamps or if there is still no limit.
+// A nil policy keeps the limit.
+func (this *LimitPolicy) Apply(limit int32) (int32, error) {
+	if limit < 0 {
+		return 0, errors.New("Invalid limit " + strconv.Itoa(int(limit)) + ", the limit cannot be negative")
+	}
+	if this == nil {
+		return limit, nil
+	}
+	if limit == 0 {
+		limit = this.DefaultLimit
+	}
+	if this.MaxLimit > 0 && (limit > this.MaxLimit || limit == 0) {
+		if this.Overflow == OverflowClamp || limit == 0 {
+			return this.MaxLimit, nil
+		}
+		return 0, errors.New("Invalid limit " + strconv.Itoa(int(limit)) + ", the max limit is " +
+			strconv.Itoa(int(this.MaxLimit)))
+	}
+	return limit, nil
+}
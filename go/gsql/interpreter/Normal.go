@@ -0,0 +1,174 @@
+package interpreter
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+)
+
+// normalNode is the where clause as a tree of n-ary and/or groups with comparators or constants as leafs.
+// Expressions & conditions are right associative, i.e. a and b or c is a and (b or c),
+// so the tree is built by grouping each node with its next node.
+type normalNode struct {
+	operation  parser.ConditionOperation
+	comparator *Comparator
+	children   []*normalNode
+	constant   bool
+	value      bool
+}
+
+var mirrored = map[parser.ComparatorOperation]parser.ComparatorOperation{
+	parser.Eq:   parser.Eq,
+	parser.Neq:  parser.Neq,
+	parser.GT:   parser.LT,
+	parser.LT:   parser.GT,
+	parser.GTEQ: parser.LTEQ,
+	parser.LTEQ: parser.GTEQ,
+}
+
+func groupOf(operation parser.ConditionOperation) parser.ConditionOperation {
+	if operation == parser.Or {
+		return parser.Or
+	}
+	return parser.And
+}
+
+func (this *Expression) normalize() *normalNode {
+	node := &normalNode{operation: groupOf(this.operation)}
+	if this.condition != nil {
+		node.children = append(node.children, this.condition.normalize())
+	}
+	if this.child != nil {
+		node.children = append(node.children, this.child.normalize())
+	}
+	if this.next != nil {
+		node.children = append(node.children, this.next.normalize())
+	}
+	return node.flatten()
+}
+
+func (this *Condition) normalize() *normalNode {
+	leaf := &normalNode{comparator: this.comparator}
+	if this.next == nil {
+		return leaf
+	}
+	node := &normalNode{operation: groupOf(this.operation)}
+	node.children = append(node.children, leaf, this.next.normalize())
+	return node.flatten()
+}
+
+// flatten merges the child groups with the same operation into this group
+// and replaces a group of one child with the child.
+func (this *normalNode) flatten() *normalNode {
+	if this.comparator != nil || this.constant {
+		return this
+	}
+	children := make([]*normalNode, 0, len(this.children))
+	for _, child := range this.children {
+		child = child.flatten()
+		if child.comparator == nil && !child.constant && child.operation == this.operation {
+			children = append(children, child.children...)
+		} else {
+			children = append(children, child)
+		}
+	}
+	this.children = children
+	if len(this.children) == 1 {
+		return this.children[0]
+	}
+	return this
+}
+
+// canonical returns the canonical form of the node, the operands of and/or groups are sorted & deduplicated
+// so logically identical trees, that differ only in the order of the operands, have the same form.
+func (this *normalNode) canonical() string {
+	if this.constant {
+		return strconv.FormatBool(this.value)
+	}
+	if this.comparator != nil {
+		return this.comparator.canonical()
+	}
+	operands := make([]string, 0, len(this.children))
+	seen := make(map[string]bool)
+	for _, child := range this.children {
+		c := child.canonical()
+		if !seen[c] {
+			seen[c] = true
+			operands = append(operands, c)
+		}
+	}
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	sort.Strings(operands)
+	buff := bytes.Buffer{}
+	buff.WriteString(strings.TrimSpace(string(this.operation)))
+	buff.WriteString("(")
+	buff.WriteString(strings.Join(operands, ","))
+	buff.WriteString(")")
+	return buff.String()
+}
+
+// canonical returns the comparator with the property on the left side and normalized literals.
+func (this *Comparator) canonical() string {
+	left := canonicalOperand(this.left, this.leftProperty, this.leftPath, this.leftParam)
+	right := canonicalOperand(this.right, this.rightProperty, this.rightPath, this.rightParam)
+	operation := this.operation
+	if this.leftProperty == nil && this.rightProperty != nil {
+		if m, ok := mirrored[operation]; ok {
+			left, right = right, left
+			operation = m
+		}
+	}
+	if (operation == parser.Eq || operation == parser.Neq) && left > right &&
+		(this.leftProperty != nil) == (this.rightProperty != nil) {
+		left, right = right, left
+	}
+	return left + strings.TrimSpace(string(operation)) + right
+}
+
+func canonicalOperand(value string, property *properties.Property, path *accessPath, param string) string {
+	if param != "" {
+		return "$" + param
+	}
+	if path != nil {
+		return "@" + path.String()
+	}
+	if property != nil {
+		pid, err := property.PropertyId()
+		if err != nil {
+			pid = value
+		}
+		return "@" + strings.ToLower(pid)
+	}
+	return normalizeLiteral(value)
+}
+
+// normalizeLiteral removes the quotes, lowers the case and sorts & deduplicates list literals,
+// the same as the comparators see the values.
+func normalizeLiteral(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		items := strings.Split(value[1:len(value)-1], ",")
+		seen := make(map[string]bool)
+		result := make([]string, 0, len(items))
+		for _, item := range items {
+			item = normalizeLiteral(item)
+			if !seen[item] {
+				seen[item] = true
+				result = append(result, item)
+			}
+		}
+		sort.Strings(result)
+		return "[" + strings.Join(result, ",") + "]"
+	}
+	// a quoted 'nil' is the string nil, not the nil keyword
+	if len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'' && value != "'nil'" {
+		value = value[1 : len(value)-1]
+	}
+	return value
+}
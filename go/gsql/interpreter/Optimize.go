@@ -0,0 +1,427 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+type NormalForm int
+
+const (
+	// AsWritten keeps the and/or structure of the where clause.
+	AsWritten NormalForm = iota
+	// CNF rewrites the where clause as an and of ors.
+	CNF
+	// DNF rewrites the where clause as an or of ands.
+	DNF
+)
+
+// MAX_NORMAL_FORM_CLAUSES limits the number of clauses of a CNF/DNF conversion, as the conversion
+// may grow exponentially with the size of the where clause.
+const MAX_NORMAL_FORM_CLAUSES = 1024
+
+// Optimized optimizes the where clause when the query is compiled, see Query.Optimize.
+func Optimized(form NormalForm) Option {
+	return func(opts *options) {
+		opts.optimize = true
+		opts.form = form
+	}
+}
+
+// Optimize returns a copy of the query with a rewritten where clause. Nested groups are flattened,
+// duplicate predicates are removed, constant comparators, e.g. 1=2, are folded and contradictions
+// & tautologies on scalar properties, e.g. x>5 and x<3, are replaced with constants. The where clause
+// is then converted to CNF or DNF if asked. A where clause that is always true is removed and a where
+// clause that is always false is replaced with 1=2.
+func (this *Query) Optimize(form NormalForm) (*Query, error) {
+	optimized := *this
+	if this.where == nil {
+		return &optimized, nil
+	}
+	node := this.where.normalize().simplify(this.resources)
+	if form != AsWritten && !node.constant {
+		outer, inner := parser.Or, parser.And
+		if form == CNF {
+			outer, inner = parser.And, parser.Or
+		}
+		clauses, err := node.clauses(outer)
+		if err != nil {
+			return nil, err
+		}
+		group := &normalNode{operation: outer}
+		for _, clause := range clauses {
+			group.children = append(group.children, &normalNode{operation: inner, children: clause})
+		}
+		node = group.simplify(this.resources)
+	}
+	if node.constant && node.value {
+		optimized.where = nil
+	} else {
+		optimized.where = node.expression()
+	}
+	optimized.compile()
+	return &optimized, nil
+}
+
+func constantNode(value bool) *normalNode {
+	return &normalNode{constant: true, value: value}
+}
+
+func (this *normalNode) isLeaf() bool {
+	return this.comparator != nil || this.constant
+}
+
+// simplify returns the node with its groups flattened, deduplicated & absorbed, e.g. a and (a or b) is a,
+// and with the constants folded.
+func (this *normalNode) simplify(resources ifs.IResources) *normalNode {
+	if this.constant {
+		return this
+	}
+	if this.comparator != nil {
+		if this.comparator.isConstant() {
+			return constantNode(this.comparator.compare(this.comparator.left, this.comparator.right))
+		}
+		return this
+	}
+	//The value that decides the group, true decides an or group & false decides an and group
+	decisive := this.operation == parser.Or
+	group := &normalNode{operation: this.operation}
+	seen := make(map[string]bool)
+	add := func(child *normalNode) {
+		c := child.canonical()
+		if !seen[c] {
+			seen[c] = true
+			group.children = append(group.children, child)
+		}
+	}
+	for _, child := range this.children {
+		child = child.simplify(resources)
+		if child.constant {
+			if child.value == decisive {
+				return constantNode(decisive)
+			}
+			continue
+		}
+		if !child.isLeaf() && child.operation == this.operation {
+			for _, grandChild := range child.children {
+				add(grandChild)
+			}
+			continue
+		}
+		add(child)
+	}
+	group.absorb()
+	if group.decided(resources) {
+		return constantNode(decisive)
+	}
+	switch len(group.children) {
+	case 0:
+		return constantNode(!decisive)
+	case 1:
+		return group.children[0]
+	}
+	return group
+}
+
+// absorb removes the child groups that contain one of the other children, e.g. a and (a or b) is a.
+func (this *normalNode) absorb() {
+	operands := make(map[string]bool)
+	for _, child := range this.children {
+		operands[child.canonical()] = true
+	}
+	children := make([]*normalNode, 0, len(this.children))
+	for _, child := range this.children {
+		if !child.isLeaf() && child.containsAny(operands) {
+			continue
+		}
+		children = append(children, child)
+	}
+	this.children = children
+}
+
+func (this *normalNode) containsAny(operands map[string]bool) bool {
+	for _, child := range this.children {
+		if operands[child.canonical()] {
+			return true
+		}
+	}
+	return false
+}
+
+// decided returns true if an and group is a contradiction or an or group is a tautology,
+// as detected from the predicates of the group on the same scalar property.
+func (this *normalNode) decided(resources ifs.IResources) bool {
+	byProperty := make(map[string][]*predicate)
+	for _, child := range this.children {
+		if child.comparator == nil {
+			continue
+		}
+		p, ok := child.comparator.predicate(resources)
+		if !ok {
+			continue
+		}
+		for _, other := range byProperty[p.property] {
+			if this.operation == parser.Or && p.complements(other) || this.operation != parser.Or && p.excludes(other) {
+				return true
+			}
+		}
+		byProperty[p.property] = append(byProperty[p.property], p)
+	}
+	return false
+}
+
+// clauses returns the node as clauses combined by the outer operation, each clause is a list of leafs
+// combined by the inner operation.
+func (this *normalNode) clauses(outer parser.ConditionOperation) ([][]*normalNode, error) {
+	if this.isLeaf() {
+		return [][]*normalNode{{this}}, nil
+	}
+	if this.operation == outer {
+		result := make([][]*normalNode, 0)
+		for _, child := range this.children {
+			clauses, err := child.clauses(outer)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, clauses...)
+			if len(result) > MAX_NORMAL_FORM_CLAUSES {
+				return nil, errors.New("Normal form exceeds " + strconv.Itoa(MAX_NORMAL_FORM_CLAUSES) + " clauses")
+			}
+		}
+		return result, nil
+	}
+	result := [][]*normalNode{{}}
+	for _, child := range this.children {
+		clauses, err := child.clauses(outer)
+		if err != nil {
+			return nil, err
+		}
+		if len(result)*len(clauses) > MAX_NORMAL_FORM_CLAUSES {
+			return nil, errors.New("Normal form exceeds " + strconv.Itoa(MAX_NORMAL_FORM_CLAUSES) + " clauses")
+		}
+		product := make([][]*normalNode, 0, len(result)*len(clauses))
+		for _, r := range result {
+			for _, c := range clauses {
+				clause := make([]*normalNode, 0, len(r)+len(c))
+				clause = append(clause, r...)
+				clause = append(clause, c...)
+				product = append(product, clause)
+			}
+		}
+		result = product
+	}
+	return result, nil
+}
+
+// expression returns the interpreter tree of the node, consecutive leafs of a group are chained as a condition.
+func (this *normalNode) expression() *Expression {
+	if this.isLeaf() {
+		return &Expression{condition: &Condition{comparator: this.leafComparator()}}
+	}
+	var head, tail *Expression
+	for i := 0; i < len(this.children); {
+		expr := &Expression{}
+		if this.children[i].isLeaf() {
+			var last *Condition
+			for ; i < len(this.children) && this.children[i].isLeaf(); i++ {
+				cond := &Condition{comparator: this.children[i].leafComparator()}
+				if last == nil {
+					expr.condition = cond
+				} else {
+					last.operation = this.operation
+					last.next = cond
+				}
+				last = cond
+			}
+		} else {
+			expr.child = this.children[i].expression()
+			i++
+		}
+		if head == nil {
+			head = expr
+		} else {
+			tail.operation = this.operation
+			tail.next = expr
+		}
+		tail = expr
+	}
+	return head
+}
+
+func (this *normalNode) leafComparator() *Comparator {
+	if !this.constant {
+		return this.comparator
+	}
+	if this.value {
+		return &Comparator{left: "1", operation: parser.Eq, right: "1"}
+	}
+	return &Comparator{left: "1", operation: parser.Eq, right: "2"}
+}
+
+// predicate is a comparator of a scalar property with a literal, with the property on the left side.
+type predicate struct {
+	property  string
+	operation parser.ComparatorOperation
+	literal   string
+	number    float64
+	numeric   bool
+}
+
+func (this *Comparator) predicate(resources ifs.IResources) (*predicate, bool) {
+	var prop *properties.Property
+	literal := ""
+	operation := this.operation
+	if this.leftProperty != nil && this.leftPath == nil && this.rightProperty == nil && this.rightParam == "" {
+		prop, literal = this.leftProperty, this.right
+	} else if this.rightProperty != nil && this.rightPath == nil && this.leftProperty == nil && this.leftParam == "" {
+		prop, literal = this.rightProperty, this.left
+		operation = mirrored[operation]
+	} else {
+		return nil, false
+	}
+	if _, ok := mirrored[operation]; !ok {
+		return nil, false
+	}
+	node := prop.Node()
+	if node == nil || !isScalar(node) {
+		return nil, false
+	}
+	literal = normalizeLiteral(literal)
+	if literal == "" || literal == "nil" || strings.Contains(literal, "*") {
+		return nil, false
+	}
+	pid, _ := prop.PropertyId()
+	p := &predicate{property: strings.ToLower(pid), operation: operation, literal: literal}
+	switch kindOf(node, resources) {
+	// the literal is parsed as the comparators of the kind parse it, a literal they do not parse,
+	// e.g. 5.5 for an int, does not match and is not folded
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(literal, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		p.number, p.numeric = float64(number), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(literal, 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		p.number, p.numeric = float64(number), true
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(literal, 64)
+		if err != nil {
+			return nil, false
+		}
+		p.number, p.numeric = number, true
+	case reflect.Bool:
+		b, err := strconv.ParseBool(literal)
+		if err != nil || p.isRange() {
+			return nil, false
+		}
+		p.literal = strconv.FormatBool(b)
+	case reflect.String:
+		if p.isRange() {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	return p, true
+}
+
+// isScalar returns true if the property has a single value, i.e. it is not under a list or a map.
+func isScalar(node *l8reflect.L8Node) bool {
+	for n := node; n != nil; n = n.Parent {
+		if n.IsSlice || n.IsMap {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *predicate) isRange() bool {
+	return this.operation == parser.GT || this.operation == parser.LT ||
+		this.operation == parser.GTEQ || this.operation == parser.LTEQ
+}
+
+func (this *predicate) isLower() bool {
+	return this.operation == parser.GT || this.operation == parser.GTEQ
+}
+
+func (this *predicate) inclusive() bool {
+	return this.operation == parser.GTEQ || this.operation == parser.LTEQ
+}
+
+func (this *predicate) same(other *predicate) bool {
+	if this.numeric && other.numeric {
+		return this.number == other.number
+	}
+	return this.literal == other.literal
+}
+
+func (this *predicate) holds(value float64) bool {
+	switch this.operation {
+	case parser.GT:
+		return value > this.number
+	case parser.GTEQ:
+		return value >= this.number
+	case parser.LT:
+		return value < this.number
+	case parser.LTEQ:
+		return value <= this.number
+	}
+	return false
+}
+
+// excludes returns true if the predicates cannot both be true.
+func (this *predicate) excludes(other *predicate) bool {
+	switch {
+	case this.operation == parser.Eq && other.operation == parser.Eq:
+		return !this.same(other)
+	case this.operation == parser.Eq && other.operation == parser.Neq,
+		this.operation == parser.Neq && other.operation == parser.Eq:
+		return this.same(other)
+	case this.operation == parser.Eq && other.isRange():
+		return !other.holds(this.number)
+	case this.isRange() && other.operation == parser.Eq:
+		return !this.holds(other.number)
+	case this.isRange() && other.isRange() && this.isLower() != other.isLower():
+		lower, upper := this, other
+		if !lower.isLower() {
+			lower, upper = other, this
+		}
+		return upper.number < lower.number ||
+			upper.number == lower.number && !(lower.inclusive() && upper.inclusive())
+	}
+	return false
+}
+
+// complements returns true if at least one of the predicates is always true.
+func (this *predicate) complements(other *predicate) bool {
+	switch {
+	case this.operation == parser.Eq && other.operation == parser.Neq,
+		this.operation == parser.Neq && other.operation == parser.Eq:
+		return this.same(other)
+	case this.operation == parser.Neq && other.operation == parser.Neq:
+		return !this.same(other)
+	case this.operation == parser.Neq && other.isRange():
+		return other.holds(this.number)
+	case this.isRange() && other.operation == parser.Neq:
+		return this.holds(other.number)
+	case this.isRange() && other.isRange() && this.isLower() != other.isLower():
+		lower, upper := this, other
+		if !lower.isLower() {
+			lower, upper = other, this
+		}
+		return upper.number > lower.number ||
+			upper.number == lower.number && (lower.inclusive() || upper.inclusive())
+	}
+	return false
+}
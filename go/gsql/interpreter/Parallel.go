@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DEFAULT_CHUNK_SIZE is the number of elements a worker of FilterParallel matches at a time,
+// the context is checked before each chunk.
+const DEFAULT_CHUNK_SIZE = 1024
+
+// ParallelOption configures FilterParallel.
+type ParallelOption func(*parallelOptions)
+
+type parallelOptions struct {
+	workers   int
+	chunkSize int
+}
+
+// Workers sets the number of workers of FilterParallel, the default is GOMAXPROCS.
+func Workers(workers int) ParallelOption {
+	return func(opts *parallelOptions) {
+		opts.workers = workers
+	}
+}
+
+// ChunkSize sets the number of elements a worker of FilterParallel matches at a time.
+func ChunkSize(chunkSize int) ParallelOption {
+	return func(opts *parallelOptions) {
+		opts.chunkSize = chunkSize
+	}
+}
+
+func newParallelOptions(opts []ParallelOption) *parallelOptions {
+	result := &parallelOptions{workers: runtime.GOMAXPROCS(0), chunkSize: DEFAULT_CHUNK_SIZE}
+	for _, opt := range opts {
+		opt(result)
+	}
+	if result.workers < 1 {
+		result.workers = 1
+	}
+	if result.chunkSize < 1 {
+		result.chunkSize = DEFAULT_CHUNK_SIZE
+	}
+	return result
+}
+
+// FilterParallel is Filter with the list partitioned into chunks that are matched by a pool of workers.
+// The result keeps the order of the list. If the context is done, the workers stop as with FilterCtx
+// and the context error is returned.
+// A compiled query is not modified when it is matched, so it is safe to match it from several goroutines.
+func (this *Query) FilterParallel(ctx context.Context, list []interface{}, onlySelectedColumns bool, opts ...ParallelOption) ([]interface{}, error) {
+	options := newParallelOptions(opts)
+	ctx, cancel := this.withTimeout(ctx)
+	defer cancel()
+	chunks := (len(list) + options.chunkSize - 1) / options.chunkSize
+	workers := options.workers
+	if workers > chunks {
+		workers = chunks
+	}
+	results := make([][]interface{}, chunks)
+	next := int64(-1)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				chunk := int(atomic.AddInt64(&next, 1))
+				if chunk >= chunks || ctx.Err() != nil {
+					return
+				}
+				from := chunk * options.chunkSize
+				to := min(from+options.chunkSize, len(list))
+				results[chunk], _ = this.filter(ctx, list[from:to], onlySelectedColumns)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, result := range results {
+		size += len(result)
+	}
+	filtered := make([]interface{}, 0, size)
+	for _, result := range results {
+		filtered = append(filtered, result...)
+	}
+	return filtered, nil
+}
@@ -0,0 +1,366 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+	"github.com/saichler/l8types/go/ifs"
+)
+
+// accessPath is a property path that uses key accessors, e.g. mystring2modelmap['newone'].mystring
+// or mymodelslice[-1].mystring, or a path function, e.g. keys(mystring2modelmap). The plain property is kept for the schema validation
+// while the values are extracted by walking the path.
+type accessPath struct {
+	text     string
+	function string
+	elements []*parser.PathElement
+	property *properties.Property
+}
+
+var fieldIndexes = sync.Map{}
+
+type fieldKey struct {
+	typ  reflect.Type
+	name string
+}
+
+func newAccessPath(operand, rootTable string, resources ifs.IResources) (*accessPath, error) {
+	path := &accessPath{text: operand}
+	fn, arg, ok := parser.PathFunction(operand)
+	if ok {
+		path.function = fn
+		operand = arg
+	}
+	elements, err := parser.SplitPath(propertyPath(operand, rootTable))
+	if err != nil {
+		return nil, err
+	}
+	path.elements = elements[1:]
+	prop, err := properties.PropertyOf(parser.StripKeys(elements), resources)
+	if err != nil {
+		return nil, errors.New("Cannot find property for " + path.text + ": " + err.Error())
+	}
+	path.property = prop
+	return path, nil
+}
+
+func (this *accessPath) String() string {
+	return this.text
+}
+
+// get returns the value the path points to. A path that fans out over a collection, uses a path
+// function or does not point to any value, e.g. a missing key, returns a list of the found values.
+// The context is checked for each of the values a path fans out to.
+func (this *accessPath) get(ctx context.Context, root interface{}) (interface{}, error) {
+	values := []reflect.Value{reflect.ValueOf(root)}
+	multi := false
+	for i, elem := range this.elements {
+		last := i == len(this.elements)-1
+		next := make([]reflect.Value, 0, len(values))
+		for _, value := range values {
+			if multi {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+			field, ok := fieldOf(value, elem.Name)
+			if !ok {
+				return nil, errors.New("Cannot find field " + elem.Name + " in " + this.text)
+			}
+			if !field.IsValid() {
+				continue
+			}
+			if elem.Key != nil {
+				found, isRange, err := valueOfKey(field, elem.Key)
+				if err != nil {
+					return nil, errors.New(err.Error() + " in " + this.text)
+				}
+				multi = multi || isRange
+				next = append(next, found...)
+				continue
+			}
+			if !last && isCollection(field) {
+				multi = true
+				next = appendElements(next, field)
+				continue
+			}
+			next = append(next, field)
+		}
+		values = next
+	}
+
+	switch this.function {
+	case parser.KeysFunction:
+		result := make([]interface{}, 0)
+		for _, value := range values {
+			value = indirect(value)
+			if !value.IsValid() {
+				continue
+			}
+			if value.Kind() != reflect.Map {
+				return nil, errors.New("keys() expects a map in " + this.text)
+			}
+			for _, key := range value.MapKeys() {
+				result = append(result, key.Interface())
+			}
+		}
+		return result, nil
+	case parser.ValuesFunction:
+		result := make([]interface{}, 0)
+		for _, value := range values {
+			value = indirect(value)
+			if !value.IsValid() {
+				continue
+			}
+			if !isCollection(value) {
+				return nil, errors.New("values() expects a map or a list in " + this.text)
+			}
+			for _, elem := range appendElements(nil, value) {
+				result = append(result, elem.Interface())
+			}
+		}
+		return result, nil
+	}
+
+	if !multi && len(values) == 1 {
+		return values[0].Interface(), nil
+	}
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		result = append(result, value.Interface())
+	}
+	return result, nil
+}
+
+// value returns the single value the path points to or nil if the path points to none or to several values.
+func (this *accessPath) value(root interface{}) (interface{}, error) {
+	v, err := this.get(context.Background(), root)
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := v.([]interface{}); ok {
+		if len(list) == 1 {
+			return list[0], nil
+		}
+		return nil, nil
+	}
+	return v, nil
+}
+
+// projectInto copies the values the path points to from src into dst, creating the lists, maps & structs
+// on the way. Lists keep their size, so elements that are not selected by an index or a range stay empty.
+func projectInto(dst, src reflect.Value, elements []*parser.PathElement) {
+	if len(elements) == 0 {
+		dst.Set(src)
+		return
+	}
+	if src.Kind() == reflect.Ptr {
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.New(src.Type().Elem()))
+		}
+		projectInto(dst.Elem(), src.Elem(), elements)
+		return
+	}
+	if src.Kind() != reflect.Struct {
+		return
+	}
+	sf, ok := fieldOf(src, elements[0].Name)
+	if !ok || !sf.IsValid() {
+		return
+	}
+	df, _ := fieldOf(dst, elements[0].Name)
+	projectKey(df, sf, elements[0].Key, elements[1:])
+}
+
+func projectKey(dst, src reflect.Value, key *parser.PathKey, rest []*parser.PathElement) {
+	if key == nil && len(rest) == 0 {
+		dst.Set(src)
+		return
+	}
+	switch src.Kind() {
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() || dst.Len() != src.Len() {
+			dst.Set(reflect.MakeSlice(src.Type(), src.Len(), src.Len()))
+		}
+		from, to := 0, src.Len()
+		if key != nil {
+			if rng, ok := key.Range(); ok {
+				from, to = rng.Bounds(src.Len())
+			} else if index, ok := key.Index(); ok {
+				if index < 0 {
+					index += src.Len()
+				}
+				if index < 0 || index >= src.Len() {
+					return
+				}
+				from, to = index, index+1
+			} else {
+				return
+			}
+		}
+		for i := from; i < to; i++ {
+			projectInto(dst.Index(i), src.Index(i), rest)
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(src.Type()))
+		}
+		if key != nil {
+			mapKey, ok := mapKeyOf(src, key)
+			if ok && src.MapIndex(mapKey).IsValid() {
+				projectMapEntry(dst, mapKey, src.MapIndex(mapKey), rest)
+			}
+			return
+		}
+		iter := src.MapRange()
+		for iter.Next() {
+			projectMapEntry(dst, iter.Key(), iter.Value(), rest)
+		}
+	default:
+		projectInto(dst, src, rest)
+	}
+}
+
+func projectMapEntry(dst, key, value reflect.Value, rest []*parser.PathElement) {
+	entry := reflect.New(value.Type()).Elem()
+	if existing := dst.MapIndex(key); existing.IsValid() {
+		entry.Set(existing)
+	}
+	projectInto(entry, value, rest)
+	dst.SetMapIndex(key, entry)
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+func isCollection(value reflect.Value) bool {
+	return value.Kind() == reflect.Slice || value.Kind() == reflect.Map
+}
+
+func appendElements(result []reflect.Value, collection reflect.Value) []reflect.Value {
+	if collection.Kind() == reflect.Slice {
+		for i := 0; i < collection.Len(); i++ {
+			result = append(result, collection.Index(i))
+		}
+		return result
+	}
+	iter := collection.MapRange()
+	for iter.Next() {
+		result = append(result, iter.Value())
+	}
+	return result
+}
+
+// fieldOf returns the struct field by its case insensitive name. A nil struct returns an invalid value.
+func fieldOf(value reflect.Value, name string) (reflect.Value, bool) {
+	value = indirect(value)
+	if !value.IsValid() {
+		return reflect.Value{}, true
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	fk := fieldKey{typ: value.Type(), name: name}
+	index, ok := fieldIndexes.Load(fk)
+	if !ok {
+		field, found := value.Type().FieldByNameFunc(func(n string) bool {
+			return strings.EqualFold(n, name)
+		})
+		if !found {
+			return reflect.Value{}, false
+		}
+		index = field.Index
+		fieldIndexes.Store(fk, index)
+	}
+	return value.FieldByIndex(index.([]int)), true
+}
+
+// valueOfKey returns the value of a map key or of a list index/range. Map keys are matched by their type,
+// an int key is parsed as a number and an enum key may be used by its name or its number.
+// An index out of the list bounds returns no value while a range is clamped to the list bounds.
+func valueOfKey(collection reflect.Value, key *parser.PathKey) ([]reflect.Value, bool, error) {
+	collection = indirect(collection)
+	if !collection.IsValid() {
+		return nil, false, nil
+	}
+	if collection.Kind() == reflect.Slice {
+		if rng, ok := key.Range(); ok {
+			from, to := rng.Bounds(collection.Len())
+			return appendElements(nil, collection.Slice(from, to)), true, nil
+		}
+		index, ok := key.Index()
+		if !ok {
+			return nil, false, errors.New("Invalid list index [" + key.String() + "]")
+		}
+		if index < 0 {
+			index += collection.Len()
+		}
+		if index < 0 || index >= collection.Len() {
+			return nil, false, nil
+		}
+		return []reflect.Value{collection.Index(index)}, false, nil
+	}
+	if collection.Kind() != reflect.Map {
+		return nil, false, errors.New("Key [" + key.String() + "] used on a value that is not a map or a list")
+	}
+	if _, ok := key.Range(); ok {
+		return nil, false, errors.New("Range [" + key.String() + "] used on a map")
+	}
+	mapKey, ok := mapKeyOf(collection, key)
+	if !ok {
+		return nil, false, nil
+	}
+	value := collection.MapIndex(mapKey)
+	if !value.IsValid() {
+		return nil, false, nil
+	}
+	return []reflect.Value{value}, false, nil
+}
+
+func mapKeyOf(collection reflect.Value, key *parser.PathKey) (reflect.Value, bool) {
+	keyType := collection.Type().Key()
+	switch keyType.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(key.Text).Convert(keyType), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(key.Text, 10, 64)
+		if err == nil {
+			return reflect.ValueOf(i).Convert(keyType), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(key.Text, 10, 64)
+		if err == nil {
+			return reflect.ValueOf(i).Convert(keyType), true
+		}
+	}
+	//Enums and other key types are matched by their string representation
+	for _, mapKey := range collection.MapKeys() {
+		if strings.EqualFold(fmt.Sprint(mapKey.Interface()), key.Text) {
+			return mapKey, true
+		}
+	}
+	return reflect.Value{}, false
+}
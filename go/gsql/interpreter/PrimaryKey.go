@@ -0,0 +1,149 @@
+package interpreter
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter/comparators"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// PrimaryKeys returns the primary keys of a primary key lookup, a where clause that matches only the
+// elements with one of the keys, e.g. id=x, id in [x,y], id=x or id=y, or id=x and age>30.
+// The primary key is taken from the decorators of the root type, a primary key of several fields is
+// not looked up. The keys are as in the query text, i.e. in lower case, so a lookup should ignore
+// the case, and the elements that are found should still be matched by the query.
+func (this *Query) PrimaryKeys() ([]string, bool) {
+	if this.where == nil || this.rootType == nil {
+		return nil, false
+	}
+	fields, err := this.resources.Introspector().Decorators().Fields(this.rootType, l8reflect.L8DecoratorType_Primary)
+	if err != nil || len(fields) != 1 {
+		return nil, false
+	}
+	property, err := properties.PropertyOf(propertyPath(fields[0], this.rootType.TypeName), this.resources)
+	if err != nil || property.Node() == nil {
+		return nil, false
+	}
+	id, _ := property.PropertyId()
+	kind := kindOf(property.Node(), this.resources)
+	return this.where.normalize().primaryKeys(strings.ToLower(id), kind)
+}
+
+func (this *normalNode) primaryKeys(id string, kind reflect.Kind) ([]string, bool) {
+	switch {
+	case this.constant:
+		if this.value {
+			return nil, false
+		}
+		return []string{}, true
+	case this.comparator != nil:
+		return this.comparator.primaryKeys(id, kind)
+	}
+	var result []string
+	found := false
+	for _, child := range this.children {
+		keys, ok := child.primaryKeys(id, kind)
+		if !ok {
+			if this.operation == parser.Or {
+				return nil, false
+			}
+			continue
+		}
+		switch {
+		case !found:
+			result = keys
+		case this.operation == parser.Or:
+			result = unionKeys(result, keys)
+		default:
+			result = intersectKeys(result, keys)
+		}
+		found = true
+	}
+	return result, found
+}
+
+func (this *Comparator) primaryKeys(id string, kind reflect.Kind) ([]string, bool) {
+	if this.leftProperty == nil || this.leftPath != nil || this.rightProperty != nil || this.rightPath != nil ||
+		this.leftParam != "" || this.rightParam != "" {
+		return nil, false
+	}
+	if pid, _ := this.leftProperty.PropertyId(); strings.ToLower(pid) != id {
+		return nil, false
+	}
+	switch this.operation {
+	case parser.Eq:
+		key, ok := primaryKeyLiteral(this.right, kind)
+		if !ok {
+			return nil, false
+		}
+		return []string{key}, true
+	case parser.IN:
+		items, ok := inItems(strings.ToLower(this.right))
+		if !ok {
+			return nil, false
+		}
+		keys := make([]string, 0, len(items))
+		for _, item := range items {
+			key, ok := primaryKeyLiteral(item, kind)
+			if !ok {
+				return nil, false
+			}
+			keys = append(keys, key)
+		}
+		return unionKeys(nil, keys), true
+	}
+	return nil, false
+}
+
+// primaryKeyLiteral returns the key of a literal compared to the primary key, false if the literal
+// may equal other keys, e.g. a wildcard or nil.
+func primaryKeyLiteral(literal string, kind reflect.Kind) (string, bool) {
+	switch {
+	case kind == reflect.String:
+		lower := strings.ToLower(literal)
+		key := comparators.Unquote(lower)
+		if key == "" || comparators.IsNil(lower) || comparators.GetWildCardSubstrings(lower) != nil {
+			return "", false
+		}
+		return key, true
+	case isIntKind(kind), isUintKind(kind):
+		i, err := strconv.Atoi(literal)
+		if err != nil || isUintKind(kind) && i < 0 {
+			return "", false
+		}
+		return strconv.Itoa(i), true
+	}
+	return "", false
+}
+
+func unionKeys(keys, other []string) []string {
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		seen[key] = true
+	}
+	for _, key := range other {
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func intersectKeys(keys, other []string) []string {
+	in := make(map[string]bool, len(other))
+	for _, key := range other {
+		in[key] = true
+	}
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if in[key] {
+			result = append(result, key)
+		}
+	}
+	return result
+}
@@ -2,11 +2,11 @@ package interpreter
 
 import (
 	"bytes"
-	"crypto/md5"
-	"encoding/hex"
+	"context"
 	"errors"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/saichler/l8ql/go/gsql/parser"
 	"github.com/saichler/l8reflect/go/reflect/properties"
@@ -19,30 +19,53 @@ type Query struct {
 	rootType       *l8reflect.L8Node
 	propertiesMap  map[string]ifs.IProperty
 	properties     []ifs.IProperty
+	paths          []*accessPath
 	where          *Expression
+	params         []string
 	sortBy         string
 	sortByProperty *properties.Property
+	sortByPath     *accessPath
+	sortKeys       []*sortKey
 	descending     bool
 	limit          int32
 	page           int32
 	matchCase      bool
+	strict         bool
+	explain        bool
+	analyze        bool
+	matcher        matchFunc
+	timeout        time.Duration
+	cursorSecret   []byte
+	after          *sortItem
 	resources      ifs.IResources
 	query          *l8api.L8Query
+	text           string
 }
 
-func NewFromQuery(query *l8api.L8Query, resources ifs.IResources) (*Query, error) {
+func NewFromQuery(query *l8api.L8Query, resources ifs.IResources, opts ...Option) (*Query, error) {
+	options := newOptions(opts)
 	iQuery := &Query{}
 	iQuery.propertiesMap = make(map[string]ifs.IProperty)
 	iQuery.properties = make([]ifs.IProperty, 0)
+	iQuery.paths = make([]*accessPath, 0)
 	iQuery.descending = query.Descending
 	iQuery.matchCase = query.MatchCase
 	iQuery.page = query.Page
-	iQuery.limit = query.Limit
 	iQuery.sortBy = query.SortBy
+	iQuery.strict = options.strict
+	iQuery.timeout = options.timeout
+	iQuery.cursorSecret = options.cursorSecret
 	iQuery.resources = resources
 	iQuery.query = query
+	iQuery.text = query.Text
 
-	err := iQuery.initTables(query)
+	limit, err := limitPolicyOf(options, resources).Apply(query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	iQuery.limit = limit
+
+	err = iQuery.initTables(query)
 	if err != nil {
 		return nil, err
 	}
@@ -57,57 +80,80 @@ func NewFromQuery(query *l8api.L8Query, resources ifs.IResources) (*Query, error
 		return nil, errors.New("root table is nil")
 	}
 
-	expr, err := CreateExpression(query.Criteria, rootTable, resources)
+	expr, err := CreateExpression(query.Criteria, rootTable, resources, opts...)
 	if err != nil {
 		return nil, err
 	}
 	iQuery.where = expr
+	if expr != nil {
+		iQuery.params = expr.params(nil)
+		if iQuery.strict {
+			err = expr.validate(resources)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if options.optimize {
+		iQuery, err = iQuery.Optimize(options.form)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if options.reorder {
+		iQuery = iQuery.Reorder()
+	}
 
 	if iQuery.sortBy != "" {
-		sortByProperty, er := properties.PropertyOf(iQuery.sortBy, resources)
-		if er != nil {
-			return nil, errors.New(er.Error())
+		err = iQuery.initSortKeys(rootTable, resources)
+		if err != nil {
+			return nil, err
 		}
-		iQuery.sortByProperty = sortByProperty
 	}
+	iQuery.compile()
 
 	return iQuery, nil
 }
 
-func NewQuery(gsql string, resources ifs.IResources) (*Query, error) {
+// NewQuery compiles the query text, a text with the explain prefix, e.g. "explain select ...",
+// is compiled as the query, with IsExplain returning true. A text with the explain analyze prefix
+// also has IsAnalyze returning true. A text with an after clause, e.g. "... limit 10 after <cursor>",
+// is compiled as the query resumed after the cursor, see After.
+func NewQuery(gsql string, resources ifs.IResources, opts ...Option) (*Query, error) {
+	text := gsql
+	gsql, explain := parser.StripExplain(gsql)
+	analyze := false
+	if explain {
+		gsql, analyze = parser.StripAnalyze(gsql)
+	}
+	gsql, cursor := parser.StripAfter(gsql)
 	pQuery, err := parser.NewQuery(gsql, resources.Logger())
 	if err != nil {
 		return nil, err
 	}
-	return NewFromQuery(pQuery.Query(), resources)
+	query, err := NewFromQuery(pQuery.Query(), resources, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		query, err = query.After(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+	query.explain = explain
+	query.analyze = analyze
+	query.text = text
+	return query, nil
 }
 
 func (this *Query) Query() *l8api.L8Query {
 	return this.query
 }
 
+// String returns the canonical L8QL text of the query, parsing it returns the same query.
 func (this *Query) String() string {
-	buff := bytes.Buffer{}
-	buff.WriteString("Select ")
-	first := true
-
-	for _, column := range this.Properties() {
-		if !first {
-			buff.WriteString(", ")
-		}
-		id, _ := column.PropertyId()
-		buff.WriteString(id)
-		first = false
-	}
-
-	buff.WriteString(" From ")
-	buff.WriteString(this.rootType.TypeName)
-
-	if this.where != nil {
-		buff.WriteString(" Where ")
-		buff.WriteString(this.where.String())
-	}
-	return buff.String()
+	return parser.Format(this.ToL8Query())
 }
 
 func (this *Query) RootType() *l8reflect.L8Node {
@@ -160,21 +206,37 @@ func (this *Query) initColumns(query *l8api.L8Query, resources ifs.IResources) e
 		return nil
 	} else {
 		for _, col := range query.Properties {
+			if parser.IsAccessPath(col) {
+				path, err := newAccessPath(col, this.rootType.TypeName, resources)
+				if err != nil {
+					return this.resources.Logger().Error("cannot find property for col ", col, ":", err.Error())
+				}
+				if path.function != "" {
+					return this.resources.Logger().Error("cannot select ", col)
+				}
+				this.propertiesMap[col] = path.property
+				this.properties = append(this.properties, path.property)
+				this.paths = append(this.paths, path)
+				continue
+			}
 			propPath := propertyPath(col, this.rootType.TypeName)
 			prop, err := properties.PropertyOf(propPath, resources)
 			if err != nil {
-				return this.resources.Logger().Error("cannot find property for col ", propPath, ":", err.Error())
+				return this.resources.Logger().Error("cannot find property for col ", propPath, ":", err.Error(),
+					suggestProperty(col, this.rootType))
 			}
 			this.propertiesMap[col] = prop
 			this.properties = append(this.properties, prop)
+			this.paths = append(this.paths, nil)
 		}
 	}
 	return nil
 }
 
 func propertyPath(colName, rootTable string) string {
+	colName = parser.Identifier(colName)
 	rootTable = strings.ToLower(rootTable)
-	if strings.Contains(colName, rootTable) {
+	if colName == rootTable || strings.HasPrefix(colName, rootTable+".") {
 		return colName
 	}
 	buff := bytes.Buffer{}
@@ -184,7 +246,7 @@ func propertyPath(colName, rootTable string) string {
 	return buff.String()
 }
 
-func (this *Query) match(root interface{}) (bool, error) {
+func (this *Query) match(ctx context.Context, root interface{}) (bool, error) {
 	if root == nil {
 		return false, nil
 	}
@@ -194,46 +256,41 @@ func (this *Query) match(root interface{}) (bool, error) {
 	if this.where == nil {
 		return true, nil
 	}
-	return this.where.Match(root)
+	if this.matcher != nil {
+		return this.matcher(ctx, root)
+	}
+	return this.where.match(ctx, root, nil)
 }
 
 func (this *Query) Filter(list []interface{}, onlySelectedColumns bool) []interface{} {
-	result := make([]interface{}, 0)
-	for _, i := range list {
-		if this.Match(i) {
-			if !onlySelectedColumns || len(this.properties) == 0 {
-				result = append(result, i)
-			} else {
-				result = append(result, this.cloneOnlyWithColumns(i))
-			}
-		}
-	}
+	result, _ := this.filter(context.Background(), list, onlySelectedColumns)
 	return result
 }
 
 func (this *Query) Match(any interface{}) bool {
-	m, e := this.match(any)
+	m, e := this.match(context.Background(), any)
 	if e != nil {
 		this.resources.Logger().Error(e)
 	}
 	return m
 }
 
+// SortByValue returns the value of the first sort key.
 func (this *Query) SortByValue(v interface{}) interface{} {
-	if this.sortBy == "" {
+	if len(this.sortKeys) == 0 {
 		return nil
 	}
-	resp, e := this.sortByProperty.Get(v)
-	if e != nil {
-		this.resources.Logger().Error(e)
-	}
-	return resp
+	return this.sortKeys[0].value(v, this.resources)
 }
 
 func (this *Query) cloneOnlyWithColumns(any interface{}) interface{} {
 	typ := reflect.ValueOf(any).Elem().Type()
 	clone := reflect.New(typ).Interface()
-	for _, column := range this.properties {
+	for i, column := range this.properties {
+		if this.paths[i] != nil {
+			projectInto(reflect.ValueOf(clone), reflect.ValueOf(any), this.paths[i].elements)
+			continue
+		}
 		v, _ := column.Get(any)
 		column.Set(clone, v)
 	}
@@ -244,27 +301,32 @@ func (this *Query) Criteria() ifs.IExpression {
 	return this.where
 }
 
+// KeyOf returns the primary key of a lookup of a single primary key, e.g. id=x, or an empty string
+// if the query is not such a lookup, see PrimaryKeys.
 func (this *Query) KeyOf() string {
-	if this.where == nil {
+	keys, ok := this.PrimaryKeys()
+	if !ok || len(keys) != 1 {
 		return ""
 	}
-	return this.where.keyOf()
+	return keys[0]
 }
 
+// Text returns the text the query was compiled from, as the caller wrote it.
 func (this *Query) Text() string {
-	return this.query.Text
+	return this.text
 }
 
-func (this *Query) Hash() string {
-	buff := bytes.Buffer{}
-	if this.rootType != nil {
-		buff.WriteString(this.rootType.TypeName)
-	}
-	if this.where != nil {
-		buff.WriteString(this.where.String())
+// withText returns the query with the text, or a copy of the query with the text if its text is another text.
+func (this *Query) withText(text string) *Query {
+	if this.text == text {
+		return this
 	}
-	buff.WriteString(this.sortBy)
-	h := md5.New()
-	h.Write(buff.Bytes())
-	return hex.EncodeToString(h.Sum(nil))
+	query := *this
+	query.text = text
+	return &query
+}
+
+// Hash returns the hash of the canonical form of the query with the default hash version.
+func (this *Query) Hash() string {
+	return this.HashOf(DEFAULT_HASH_VERSION)
 }
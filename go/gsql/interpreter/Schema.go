@@ -0,0 +1,228 @@
+package interpreter
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// Option configures how a query is compiled.
+type Option func(*options)
+
+type options struct {
+	strict       bool
+	optimize     bool
+	form         NormalForm
+	reorder      bool
+	timeout      time.Duration
+	cursorSecret []byte
+	limits       *LimitPolicy
+}
+
+// Strict validates the where clause against the schema of the root type when the query is compiled
+// and when it is bound. Literals must be convertible to the kind of the field they are compared to,
+// e.g. a number for an int32 field, and the operator must be supported by the kind, e.g. > is not
+// supported for a struct.
+func Strict() Option {
+	return func(opts *options) {
+		opts.strict = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	result := &options{}
+	for _, opt := range opts {
+		opt(result)
+	}
+	return result
+}
+
+// suggestProperty returns a "did you mean" suffix for an operand that does not resolve to a field
+// of the root type, or an empty string if the operand resolves or there is no close field name.
+func suggestProperty(operand string, rootType *l8reflect.L8Node) string {
+	if _, arg, ok := parser.PathFunction(operand); ok {
+		operand = arg
+	}
+	elements, err := parser.SplitPath(operand)
+	if err != nil || len(elements) == 0 {
+		return ""
+	}
+	if strings.EqualFold(elements[0].Name, rootType.TypeName) {
+		elements = elements[1:]
+	}
+	node := rootType
+	for i, elem := range elements {
+		child := attributeOf(node, elem.Name)
+		if child != nil {
+			node = child
+			continue
+		}
+		suggestion := parser.Suggest(elem.Name, attributeNames(node))
+		if suggestion == "" {
+			return ""
+		}
+		names := make([]string, 0, len(elements))
+		for _, e := range elements[:i] {
+			names = append(names, e.Name)
+		}
+		names = append(names, suggestion)
+		for _, e := range elements[i+1:] {
+			names = append(names, e.Name)
+		}
+		return ", did you mean " + strings.Join(names, ".") + "?"
+	}
+	return ""
+}
+
+func attributeOf(node *l8reflect.L8Node, name string) *l8reflect.L8Node {
+	if node == nil || node.Attributes == nil {
+		return nil
+	}
+	if attr, ok := node.Attributes[name]; ok {
+		return attr
+	}
+	for key, attr := range node.Attributes {
+		if strings.EqualFold(key, name) {
+			return attr
+		}
+	}
+	return nil
+}
+
+func attributeNames(node *l8reflect.L8Node) []string {
+	names := make([]string, 0, len(node.Attributes))
+	for key := range node.Attributes {
+		names = append(names, strings.ToLower(key))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// comparators returns the comparators of the expression by their order in the expression.
+func (this *Expression) comparators(result []*Comparator) []*Comparator {
+	if this.condition != nil {
+		result = this.condition.comparators(result)
+	}
+	if this.child != nil {
+		result = this.child.comparators(result)
+	}
+	if this.next != nil {
+		result = this.next.comparators(result)
+	}
+	return result
+}
+
+func (this *Condition) comparators(result []*Comparator) []*Comparator {
+	if this.comparator != nil {
+		result = append(result, this.comparator)
+	}
+	if this.next != nil {
+		result = this.next.comparators(result)
+	}
+	return result
+}
+
+func (this *Expression) validate(resources ifs.IResources) error {
+	for _, cmp := range this.comparators(nil) {
+		if err := cmp.validate(resources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *Comparator) validate(resources ifs.IResources) error {
+	err := validateOperand(this.leftPath, this.leftProperty, this.operation, this.right,
+		this.rightProperty == nil && this.rightParam == "", resources)
+	if err != nil {
+		return err
+	}
+	return validateOperand(this.rightPath, this.rightProperty, this.operation, this.left,
+		this.leftProperty == nil && this.leftParam == "", resources)
+}
+
+// validateOperand validates the operator against the kind of the field and the literal
+// on the other side of the comparator, if there is one, against the kind of the field.
+func validateOperand(path *accessPath, property *properties.Property, operation parser.ComparatorOperation,
+	other string, otherIsLiteral bool, resources ifs.IResources) error {
+	if property == nil || (path != nil && path.function != "") {
+		return nil
+	}
+	node := property.Node()
+	if node == nil {
+		return nil
+	}
+	kind := kindOf(node, resources)
+	pid, _ := property.PropertyId()
+	switch operation {
+	case parser.GT, parser.LT, parser.GTEQ, parser.LTEQ:
+		if kind == reflect.Struct || kind == reflect.Map || kind == reflect.Bool {
+			return errors.New("Operator " + strings.TrimSpace(string(operation)) + " is not supported for " +
+				pid + " of kind " + kind.String())
+		}
+	}
+	if !otherIsLiteral {
+		return nil
+	}
+	other = strings.TrimSpace(other)
+	if operation == parser.IN || operation == parser.NOTIN {
+		if strings.HasPrefix(other, "[") && strings.HasSuffix(other, "]") {
+			for _, item := range strings.Split(other[1:len(other)-1], ",") {
+				if err := validateLiteral(strings.TrimSpace(item), kind, pid); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return validateLiteral(other, kind, pid)
+}
+
+func kindOf(node *l8reflect.L8Node, resources ifs.IResources) reflect.Kind {
+	if node.IsMap {
+		return reflect.Map
+	}
+	if node.IsStruct {
+		return reflect.Struct
+	}
+	return resources.Introspector().Kind(node)
+}
+
+func validateLiteral(literal string, kind reflect.Kind, pid string) error {
+	if strings.ToLower(literal) == "nil" {
+		return nil
+	}
+	value := literal
+	if len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		value = value[1 : len(value)-1]
+	}
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return errors.New("Expected an integer for " + pid + " but got " + literal)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return errors.New("Expected a non negative integer for " + pid + " but got " + literal)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return errors.New("Expected a number for " + pid + " but got " + literal)
+		}
+	case reflect.Bool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errors.New("Expected true or false for " + pid + " but got " + literal)
+		}
+	case reflect.Struct, reflect.Map:
+		return errors.New("Cannot compare " + pid + " of kind " + kind.String() + " to " + literal)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package interpreter
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"strings"
+)
+
+// SKIPLIST_MAX_LEVEL is the number of levels of an ordered index, enough for 4^16 entries.
+const SKIPLIST_MAX_LEVEL = 16
+
+// skiplist is the ordered index, the entries are ordered by their key and then by their sequence.
+type skiplist struct {
+	head  *skipNode
+	level int
+}
+
+type skipNode struct {
+	key   interface{}
+	entry *entry
+	next  []*skipNode
+}
+
+func newSkiplist() *skiplist {
+	return &skiplist{head: &skipNode{next: make([]*skipNode, SKIPLIST_MAX_LEVEL)}, level: 1}
+}
+
+// before returns true if the node is ordered before the key & entry.
+func (this *skipNode) before(key interface{}, e *entry) bool {
+	c := compareIndexKeys(this.key, key)
+	return c < 0 || c == 0 && this.entry.seq < e.seq
+}
+
+// path returns the last node before the key & entry in each level.
+func (this *skiplist) path(key interface{}, e *entry) []*skipNode {
+	update := make([]*skipNode, SKIPLIST_MAX_LEVEL)
+	node := this.head
+	for level := this.level - 1; level >= 0; level-- {
+		for node.next[level] != nil && node.next[level].before(key, e) {
+			node = node.next[level]
+		}
+		update[level] = node
+	}
+	return update
+}
+
+func (this *skiplist) insert(key interface{}, e *entry) {
+	update := this.path(key, e)
+	level := 1
+	for level < SKIPLIST_MAX_LEVEL && rand.IntN(4) == 0 {
+		level++
+	}
+	for ; this.level < level; this.level++ {
+		update[this.level] = this.head
+	}
+	node := &skipNode{key: key, entry: e, next: make([]*skipNode, level)}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+}
+
+func (this *skiplist) remove(key interface{}, e *entry) {
+	update := this.path(key, e)
+	node := update[0].next[0]
+	if node == nil || node.entry != e {
+		return
+	}
+	for i := 0; i < len(node.next); i++ {
+		update[i].next[i] = node.next[i]
+	}
+	for this.level > 1 && this.head.next[this.level-1] == nil {
+		this.level--
+	}
+}
+
+// scan calls do with the entries with a key in the range, a nil bound is unbounded.
+func (this *skiplist) scan(lower interface{}, lowerInclusive bool, upper interface{}, upperInclusive bool, do func(*entry)) {
+	node := this.head
+	if lower != nil {
+		for level := this.level - 1; level >= 0; level-- {
+			for node.next[level] != nil {
+				c := compareIndexKeys(node.next[level].key, lower)
+				if c > 0 || c == 0 && lowerInclusive {
+					break
+				}
+				node = node.next[level]
+			}
+		}
+	}
+	for node = node.next[0]; node != nil; node = node.next[0] {
+		if upper != nil {
+			c := compareIndexKeys(node.key, upper)
+			if c > 0 || c == 0 && !upperInclusive {
+				return
+			}
+		}
+		do(node.entry)
+	}
+}
+
+// compareIndexKeys compares keys of the same index, the keys of an index have a single type.
+func compareIndexKeys(a, b interface{}) int {
+	switch key := a.(type) {
+	case int64:
+		return cmp.Compare(key, b.(int64))
+	case uint64:
+		return cmp.Compare(key, b.(uint64))
+	case string:
+		return strings.Compare(key, b.(string))
+	case bool:
+		return cmp.Compare(boolOrder(key), boolOrder(b.(bool)))
+	}
+	return 0
+}
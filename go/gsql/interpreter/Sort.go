@@ -0,0 +1,375 @@
+package interpreter
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	"github.com/saichler/l8reflect/go/reflect/properties"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/types/l8reflect"
+)
+
+// sortKey is a resolved key of the sort-by clause.
+type sortKey struct {
+	key      *parser.SortKey
+	property *properties.Property
+	path     *accessPath
+}
+
+func (this *Query) initSortKeys(rootTable *l8reflect.L8Node, resources ifs.IResources) error {
+	keys, err := parser.ParseSortBy(this.sortBy)
+	if err != nil {
+		return err
+	}
+	this.sortBy = parser.FormatSortBy(keys)
+	this.sortKeys = make([]*sortKey, 0, len(keys))
+	for _, key := range keys {
+		resolved := &sortKey{key: key}
+		if parser.IsAccessPath(key.Property) {
+			path, er := newAccessPath(key.Property, rootTable.TypeName, resources)
+			if er != nil {
+				return er
+			}
+			if path.function != "" {
+				return errors.New("Cannot sort by " + key.Property)
+			}
+			resolved.path = path
+			resolved.property = path.property
+		} else {
+			property, er := properties.PropertyOf(propertyPath(key.Property, rootTable.TypeName), resources)
+			if er != nil {
+				return errors.New(er.Error() + suggestProperty(key.Property, rootTable))
+			}
+			resolved.property = property
+		}
+		this.sortKeys = append(this.sortKeys, resolved)
+	}
+	this.sortByProperty = this.sortKeys[0].property
+	this.sortByPath = this.sortKeys[0].path
+	return nil
+}
+
+func (this *sortKey) value(v interface{}, resources ifs.IResources) interface{} {
+	var resp interface{}
+	var e error
+	if this.path != nil {
+		resp, e = this.path.value(v)
+	} else {
+		resp, e = this.property.Get(v)
+	}
+	if e != nil {
+		resources.Logger().Error(e)
+	}
+	return resp
+}
+
+// String returns the key with the resolved property id.
+func (this *sortKey) String() string {
+	key := *this.key
+	if this.path != nil {
+		key.Property = this.path.String()
+	} else {
+		key.Property, _ = this.property.PropertyId()
+	}
+	return key.String()
+}
+
+// sortItem is an element with the values of its sort keys, the sequence keeps the sort stable.
+// The value of the first key is kept apart so a single key sort does not allocate the values.
+// The key is the primary key of the element when it is paged by a cursor.
+type sortItem struct {
+	elem  interface{}
+	value interface{}
+	more  []interface{}
+	key   string
+	seq   int
+}
+
+func (this *Query) sortItem(elem interface{}, seq int) sortItem {
+	item := sortItem{elem: elem, value: this.SortByValue(elem), seq: seq}
+	if len(this.sortKeys) > 1 {
+		item.more = make([]interface{}, 0, len(this.sortKeys)-1)
+		for _, key := range this.sortKeys[1:] {
+			item.more = append(item.more, key.value(elem, this.resources))
+		}
+	}
+	return item
+}
+
+// before returns true if the item is sorted before the other item, equal values keep the input order.
+func (this *Query) before(item, other sortItem) bool {
+	c := this.compareItems(item, other)
+	if c != 0 {
+		return c < 0
+	}
+	return item.seq < other.seq
+}
+
+// compareItems compares the items by the values of the sort keys and then by the primary keys.
+func (this *Query) compareItems(item, other sortItem) int {
+	c := 0
+	if len(this.sortKeys) > 0 {
+		c = this.compareKey(this.sortKeys[0], item.value, other.value)
+	}
+	for i := 0; c == 0 && i < len(item.more); i++ {
+		c = this.compareKey(this.sortKeys[i+1], item.more[i], other.more[i])
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(item.key, other.key)
+}
+
+// compareKey compares the values of the key by its direction, or the direction of the query if the key
+// has none, with the nulls positioned by the nulls order of the key.
+func (this *Query) compareKey(key *sortKey, a, b interface{}) int {
+	descending := this.descending
+	if key.key.HasDirection {
+		descending = key.key.Descending
+	}
+	aNull, bNull := isNull(a), isNull(b)
+	if aNull || bNull {
+		if aNull && bNull {
+			return 0
+		}
+		c := 1
+		if aNull {
+			c = -1
+		}
+		switch key.key.Nulls {
+		case parser.NullsFirst:
+			return c
+		case parser.NullsLast:
+			return -c
+		}
+		if descending {
+			return -c
+		}
+		return c
+	}
+	collation := key.key.Collation
+	if collation == parser.CollationDefault {
+		collation = parser.CollationNoCase
+		if this.matchCase {
+			collation = parser.CollationBinary
+		}
+	}
+	c := compareSortValues(a, b, collation)
+	if descending {
+		return -c
+	}
+	return c
+}
+
+func isNull(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
+
+// compareSortValues compares values of the same kind, values of different kinds are compared by their text.
+func compareSortValues(a, b interface{}, collation parser.Collation) int {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case va.CanInt() && vb.CanInt():
+		return cmp.Compare(va.Int(), vb.Int())
+	case va.CanUint() && vb.CanUint():
+		return cmp.Compare(va.Uint(), vb.Uint())
+	case va.CanFloat() && vb.CanFloat():
+		return cmp.Compare(va.Float(), vb.Float())
+	case va.Kind() == reflect.Bool && vb.Kind() == reflect.Bool:
+		return cmp.Compare(boolOrder(va.Bool()), boolOrder(vb.Bool()))
+	case va.Kind() == reflect.String && vb.Kind() == reflect.String:
+		return compareStrings(va.String(), vb.String(), collation)
+	}
+	return compareStrings(fmt.Sprint(a), fmt.Sprint(b), collation)
+}
+
+func compareStrings(a, b string, collation parser.Collation) int {
+	switch collation {
+	case parser.CollationBinary:
+		return strings.Compare(a, b)
+	case parser.CollationNatural:
+		return compareNatural(strings.ToLower(a), strings.ToLower(b))
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// compareNatural compares the strings with the runs of digits compared as numbers, e.g. a2 is before a10.
+func compareNatural(a, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			na, nb := digitsPrefix(a), digitsPrefix(b)
+			ta, tb := strings.TrimLeft(a[:na], "0"), strings.TrimLeft(b[:nb], "0")
+			if c := cmp.Compare(len(ta), len(tb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(ta, tb); c != 0 {
+				return c
+			}
+			a, b = a[na:], b[nb:]
+			continue
+		}
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func digitsPrefix(s string) int {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+func boolOrder(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// topK keeps the first k items by the sort order of the query in a heap of bounded size,
+// with the last of the kept items at the top of the heap.
+type topK struct {
+	query *Query
+	k     int
+	items []sortItem
+}
+
+// newTopK creates the heap of the first k items, the heap grows as the items are added,
+// so a large k does not allocate more than the items that were added.
+func newTopK(query *Query, k int) *topK {
+	return &topK{query: query, k: k}
+}
+
+// after returns true if the item at i is sorted after the item at j, i.e. it is closer to the top.
+func (this *topK) after(i, j int) bool {
+	return this.query.before(this.items[j], this.items[i])
+}
+
+// add keeps the item if it is one of the first k items so far.
+func (this *topK) add(item sortItem) {
+	if len(this.items) < this.k {
+		this.items = append(this.items, item)
+		this.up(len(this.items) - 1)
+		return
+	}
+	if this.k > 0 && this.query.before(item, this.items[0]) {
+		this.items[0] = item
+		this.down(0)
+	}
+}
+
+func (this *topK) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !this.after(i, parent) {
+			return
+		}
+		this.items[i], this.items[parent] = this.items[parent], this.items[i]
+		i = parent
+	}
+}
+
+func (this *topK) down(i int) {
+	for {
+		largest := i
+		for _, child := range []int{2*i + 1, 2*i + 2} {
+			if child < len(this.items) && this.after(child, largest) {
+				largest = child
+			}
+		}
+		if largest == i {
+			return
+		}
+		this.items[i], this.items[largest] = this.items[largest], this.items[i]
+		i = largest
+	}
+}
+
+// sorted returns the kept items by the sort order.
+func (this *topK) sorted() []sortItem {
+	this.query.sort(this.items)
+	return this.items
+}
+
+// TOP_K_RATIO is the minimal ratio of the list size to the number of elements up to the end of the page,
+// (page+1)*limit, for SortAndPage to keep them in a bounded heap instead of sorting the whole list.
+const TOP_K_RATIO = 4
+
+// SortAndPage returns the page of the list sorted by the sort-by of the query. When the end of the page
+// is at the start of a large list, only the elements up to the end of the page are kept & sorted, in a
+// bounded heap, instead of sorting the whole list. Equal values keep the order of the list.
+func (this *Query) SortAndPage(list []interface{}) []interface{} {
+	if this.sortBy == "" {
+		from, to := this.pageOf(len(list))
+		result := make([]interface{}, to-from)
+		copy(result, list[from:to])
+		return result
+	}
+	var items []sortItem
+	k := this.pageEnd()
+	if k > 0 && k <= int64(len(list)/TOP_K_RATIO) {
+		top := newTopK(this, int(k))
+		for i, elem := range list {
+			top.add(this.sortItem(elem, i))
+		}
+		items = top.sorted()
+	} else {
+		items = make([]sortItem, 0, len(list))
+		for i, elem := range list {
+			items = append(items, this.sortItem(elem, i))
+		}
+		this.sort(items)
+	}
+	from, to := this.pageOf(len(items))
+	result := make([]interface{}, 0, to-from)
+	for _, item := range items[from:to] {
+		result = append(result, item.elem)
+	}
+	return result
+}
+
+func (this *Query) sort(items []sortItem) {
+	sort.Slice(items, func(i, j int) bool {
+		return this.before(items[i], items[j])
+	})
+}
+
+// pageOf returns the range of the page in a list of the size, the whole list if there is no limit.
+func (this *Query) pageOf(size int) (int, int) {
+	if this.limit <= 0 {
+		return 0, size
+	}
+	from := int(min(int64(this.page)*int64(this.limit), int64(size)))
+	return from, min(from+int(this.limit), size)
+}
+
+// pageEnd returns the number of elements up to the end of the page, (page+1)*limit, or zero if there is
+// no limit. It is computed in int64, as page+1 and the product overflow int32 for large pages & limits.
+func (this *Query) pageEnd() int64 {
+	if this.limit <= 0 {
+		return 0
+	}
+	return (int64(this.page) + 1) * int64(this.limit)
+}
@@ -0,0 +1,104 @@
+package interpreter
+
+import (
+	"context"
+	"iter"
+	"math"
+)
+
+// Stream returns the elements of the input that match the query, lazily, with the selected columns
+// and with the page & limit applied. A query with no sort-by reads only as much of the input as the
+// page needs. A query with sort-by reads the whole input first, with a limit it keeps only the first
+// (page+1)*limit elements in a heap that grows with the matching elements, otherwise it keeps all the matching elements.
+func (this *Query) Stream(in iter.Seq[any]) iter.Seq[any] {
+	return func(yield func(any) bool) {
+		if this.sortBy != "" {
+			this.streamSorted(in, yield)
+			return
+		}
+		skip := int64(this.page) * int64(this.limit)
+		count := 0
+		for elem := range in {
+			if !this.Match(elem) {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if !yield(this.project(elem)) {
+				return
+			}
+			count++
+			if this.limit > 0 && count == int(this.limit) {
+				return
+			}
+		}
+	}
+}
+
+func (this *Query) streamSorted(in iter.Seq[any], yield func(any) bool) {
+	var items []sortItem
+	seq := 0
+	if k := this.pageEnd(); k > 0 {
+		top := newTopK(this, int(min(k, math.MaxInt)))
+		for elem := range in {
+			if this.Match(elem) {
+				top.add(this.sortItem(elem, seq))
+				seq++
+			}
+		}
+		items = top.sorted()
+	} else {
+		for elem := range in {
+			if this.Match(elem) {
+				items = append(items, this.sortItem(elem, seq))
+				seq++
+			}
+		}
+		this.sort(items)
+	}
+	from, to := this.pageOf(len(items))
+	for _, item := range items[from:to] {
+		if !yield(this.project(item.elem)) {
+			return
+		}
+	}
+}
+
+// StreamChan is Stream over channels, the output channel is closed when the input channel is closed,
+// when the page is complete or when the context is done.
+func (this *Query) StreamChan(ctx context.Context, in <-chan any) <-chan any {
+	out := make(chan any)
+	input := func(yield func(any) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case elem, ok := <-in:
+				if !ok || !yield(elem) {
+					return
+				}
+			}
+		}
+	}
+	go func() {
+		defer close(out)
+		for elem := range this.Stream(input) {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- elem:
+			}
+		}
+	}()
+	return out
+}
+
+// project returns the element with only the selected columns, or the element if all the columns are selected.
+func (this *Query) project(elem interface{}) interface{} {
+	if len(this.properties) == 0 {
+		return elem
+	}
+	return this.cloneOnlyWithColumns(elem)
+}
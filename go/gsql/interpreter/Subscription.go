@@ -0,0 +1,160 @@
+package interpreter
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ChangeType is the type of a change of the elements that match a subscribed query.
+type ChangeType int
+
+const (
+	// Added is an element that started to match the query, it was inserted or updated to match.
+	Added ChangeType = iota
+	// Removed is an element that stopped to match the query, it was deleted or updated not to match.
+	Removed
+	// Modified is an element that matches the query and was updated.
+	Modified
+)
+
+func (this ChangeType) String() string {
+	switch this {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	}
+	return "modified"
+}
+
+// Change is a change of the elements that match a subscribed query. The element is the element after
+// the change, or before it for Removed, and the previous element is the element before a Modified change.
+// With only the selected columns, both have only the selected columns of the query.
+type Change struct {
+	Type     ChangeType
+	Key      string
+	Element  interface{}
+	Previous interface{}
+}
+
+// Subscriptions are the continuous queries over a collection, they are fed with the writes of the
+// collection and notify the changes of the elements that match each query.
+type Subscriptions struct {
+	mtx           sync.RWMutex
+	subscriptions []*Subscription
+}
+
+// Subscription is a query that is notified of the changes of the elements that match it.
+type Subscription struct {
+	query               *Query
+	onlySelectedColumns bool
+	handler             func(*Change)
+	subscriptions       *Subscriptions
+	closed              atomic.Bool
+	handling            sync.RWMutex
+}
+
+func NewSubscriptions() *Subscriptions {
+	return &Subscriptions{subscriptions: make([]*Subscription, 0)}
+}
+
+// Subscribe registers the query, the handler is called with the changes of the elements that match it,
+// with only the selected columns if onlySelectedColumns is true. The handler is called synchronously
+// by the write, in the order of the writes.
+func (this *Subscriptions) Subscribe(query *Query, onlySelectedColumns bool, handler func(*Change)) *Subscription {
+	subscription := &Subscription{query: query, onlySelectedColumns: onlySelectedColumns, handler: handler, subscriptions: this}
+	this.mtx.Lock()
+	defer this.mtx.Unlock()
+	subscriptions := make([]*Subscription, 0, len(this.subscriptions)+1)
+	this.subscriptions = append(append(subscriptions, this.subscriptions...), subscription)
+	return subscription
+}
+
+// Put notifies an insert of the element, when there is no previous element, or an update of the
+// previous element. The previous element should not be the element itself modified in place,
+// as it is matched in its state before the update.
+func (this *Subscriptions) Put(key string, previous, elem interface{}) {
+	for _, subscription := range this.list() {
+		subscription.notify(key, previous, elem)
+	}
+}
+
+// Delete notifies a delete of the element.
+func (this *Subscriptions) Delete(key string, previous interface{}) {
+	for _, subscription := range this.list() {
+		subscription.notify(key, previous, nil)
+	}
+}
+
+// Len returns the number of subscriptions.
+func (this *Subscriptions) Len() int {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return len(this.subscriptions)
+}
+
+// list returns the subscriptions, the list is replaced and not modified by Subscribe & Close,
+// so a handler can subscribe or close a subscription while it is notified.
+func (this *Subscriptions) list() []*Subscription {
+	this.mtx.RLock()
+	defer this.mtx.RUnlock()
+	return this.subscriptions
+}
+
+// Query returns the subscribed query.
+func (this *Subscription) Query() *Query {
+	return this.query
+}
+
+// Close removes the subscription and waits for the running calls of its handler to return, so the
+// handler is not called after Close returns. As Close waits for the handler, a handler must not call
+// Close of its own subscription, it may close it in another goroutine.
+func (this *Subscription) Close() {
+	if this.closed.Swap(true) {
+		return
+	}
+	this.handling.Lock()
+	this.handling.Unlock()
+	this.subscriptions.mtx.Lock()
+	defer this.subscriptions.mtx.Unlock()
+	subscriptions := make([]*Subscription, 0, len(this.subscriptions.subscriptions))
+	for _, subscription := range this.subscriptions.subscriptions {
+		if subscription != this {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	this.subscriptions.subscriptions = subscriptions
+}
+
+// notify matches the element before & after the write and calls the handler with the change, if any.
+// An update that does not change the element, or its selected columns, is not a change. The handling
+// lock is held from the closed check to the return of the handler, so Close waits for the handler.
+func (this *Subscription) notify(key string, previous, elem interface{}) {
+	this.handling.RLock()
+	defer this.handling.RUnlock()
+	if this.closed.Load() {
+		return
+	}
+	before := previous != nil && this.query.Match(previous)
+	after := elem != nil && this.query.Match(elem)
+	if !before && !after {
+		return
+	}
+	if this.onlySelectedColumns {
+		if before {
+			previous = this.query.project(previous)
+		}
+		if after {
+			elem = this.query.project(elem)
+		}
+	}
+	switch {
+	case !before:
+		this.handler(&Change{Type: Added, Key: key, Element: elem})
+	case !after:
+		this.handler(&Change{Type: Removed, Key: key, Element: previous})
+	case !reflect.DeepEqual(previous, elem):
+		this.handler(&Change{Type: Modified, Key: key, Element: elem, Previous: previous})
+	}
+}
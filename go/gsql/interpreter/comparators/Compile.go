@@ -0,0 +1,170 @@
+package comparators
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Matcher compares a value to the literal it was compiled with. The second return value is false if
+// the value is not of the compiled kind, the value should then be compared with Compare.
+type Matcher func(value interface{}) (bool, bool)
+
+func isInt(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUint(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uint64
+}
+
+func int64Of(v interface{}) (int64, bool) {
+	switch value := v.(type) {
+	case int:
+		return int64(value), true
+	case int8:
+		return int64(value), true
+	case int16:
+		return int64(value), true
+	case int32:
+		return int64(value), true
+	case int64:
+		return value, true
+	}
+	value := reflect.ValueOf(v)
+	if isInt(value.Kind()) {
+		return value.Int(), true
+	}
+	return 0, false
+}
+
+func uint64Of(v interface{}) (uint64, bool) {
+	switch value := v.(type) {
+	case uint:
+		return uint64(value), true
+	case uint8:
+		return uint64(value), true
+	case uint16:
+		return uint64(value), true
+	case uint32:
+		return uint64(value), true
+	case uint64:
+		return value, true
+	}
+	value := reflect.ValueOf(v)
+	if isUint(value.Kind()) {
+		return value.Uint(), true
+	}
+	return 0, false
+}
+
+func compareInt64(a, z int64) int {
+	if a < z {
+		return -1
+	}
+	if a > z {
+		return 1
+	}
+	return 0
+}
+
+func compareUint64(a, z uint64) int {
+	if a < z {
+		return -1
+	}
+	if a > z {
+		return 1
+	}
+	return 0
+}
+
+// compileOrder compiles a comparator that accepts the result of comparing the value to the literal,
+// -1, 0 or 1, the literal is parsed once for the kind.
+func compileOrder(kind reflect.Kind, literal string, accept func(int) bool) Matcher {
+	switch {
+	case kind == reflect.String:
+		zside := Unquote(strings.ToLower(literal))
+		return func(value interface{}) (bool, bool) {
+			s, ok := value.(string)
+			if !ok {
+				return false, false
+			}
+			return accept(strings.Compare(removeSingleQuote(strings.ToLower(s)), zside)), true
+		}
+	case isInt(kind):
+		zside, zok := getInt64(literal)
+		return func(value interface{}) (bool, bool) {
+			aside, ok := int64Of(value)
+			if !ok {
+				return false, false
+			}
+			return zok && accept(compareInt64(aside, zside)), true
+		}
+	case isUint(kind):
+		zside, zok := getUint64(literal)
+		return func(value interface{}) (bool, bool) {
+			aside, ok := uint64Of(value)
+			if !ok {
+				return false, false
+			}
+			return zok && accept(compareUint64(aside, zside)), true
+		}
+	}
+	return nil
+}
+
+// compileBool compiles a comparator of a bool value that accepts whether the value equals the literal.
+func compileBool(literal string, accept func(bool) bool) Matcher {
+	zside, zok := getBool(literal)
+	return func(value interface{}) (bool, bool) {
+		b, ok := value.(bool)
+		if !ok {
+			return false, false
+		}
+		return zok && accept(b == zside), true
+	}
+}
+
+// compileIn compiles the in/not in comparators, the list items are parsed once. As with Compare,
+// the items after an item that is not a number are ignored for an int or uint kind.
+func compileIn(kind reflect.Kind, literal string, negate bool) Matcher {
+	lower := strings.ToLower(literal)
+	index := strings.Index(lower, "[")
+	if index2 := strings.Index(lower, "]"); index == -1 || index2 <= index {
+		return nil
+	}
+	items := getInStringList(lower)
+	switch {
+	case kind == reflect.String:
+		set := make(map[string]bool, len(items))
+		for _, item := range items {
+			set[item] = true
+		}
+		return func(value interface{}) (bool, bool) {
+			s, ok := value.(string)
+			if !ok {
+				return false, false
+			}
+			return set[removeSingleQuote(strings.ToLower(s))] != negate, true
+		}
+	case isInt(kind), isUint(kind):
+		set := make(map[int64]bool, len(items))
+		for _, item := range items {
+			i, e := strconv.Atoi(item)
+			if e != nil {
+				break
+			}
+			set[int64(i)] = true
+		}
+		return func(value interface{}) (bool, bool) {
+			if aside, ok := int64Of(value); ok {
+				return set[aside] != negate, true
+			}
+			if aside, ok := uint64Of(value); ok {
+				return set[int64(aside)] != negate, true
+			}
+			return false, false
+		}
+	}
+	return nil
+}
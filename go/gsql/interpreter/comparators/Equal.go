@@ -25,6 +25,7 @@ func NewEqual() *Equal {
 	c.compares[reflect.Uint32] = eqUintMatcher
 	c.compares[reflect.Uint64] = eqUintMatcher
 	c.compares[reflect.Ptr] = eqPtrMatcher
+	c.compares[reflect.Bool] = eqBoolMatcher
 	return c
 }
 
@@ -32,17 +33,69 @@ func (equal *Equal) Compare(left, right interface{}) bool {
 	return Compare(left, right, equal.compares, "Equal")
 }
 
+// Compile returns a matcher of the kind for the literal, or nil if the kind is not supported.
+func (equal *Equal) Compile(kind reflect.Kind, literal string) Matcher {
+	switch {
+	case kind == reflect.String:
+		zside, splits := parseLiteral(strings.ToLower(literal))
+		isNil := IsNil(literal)
+		return func(value interface{}) (bool, bool) {
+			s, ok := value.(string)
+			if !ok {
+				return false, false
+			}
+			return eqString(removeSingleQuote(strings.ToLower(s)), zside, splits, isNil), true
+		}
+	case isInt(kind):
+		zside, zok := getInt64(literal)
+		isNil := literal == "nil"
+		return func(value interface{}) (bool, bool) {
+			aside, ok := int64Of(value)
+			if !ok {
+				return false, false
+			}
+			if isNil && aside == 0 {
+				return true, true
+			}
+			return zok && aside == zside, true
+		}
+	case kind == reflect.Bool:
+		return compileBool(literal, func(equal bool) bool { return equal })
+	}
+	return compileOrder(kind, literal, func(c int) bool { return c == 0 })
+}
+
 func Compare(left, right interface{}, compares map[reflect.Kind]func(interface{}, interface{}) bool, name string) bool {
+	return compare(left, right, compares, name, false)
+}
+
+// CompareAll is used by the negative comparators, when the left side is a list,
+// e.g. keys(map), all the elements must match instead of any of the elements.
+func CompareAll(left, right interface{}, compares map[reflect.Kind]func(interface{}, interface{}) bool, name string) bool {
+	return compare(left, right, compares, name, true)
+}
+
+func compare(left, right interface{}, compares map[reflect.Kind]func(interface{}, interface{}) bool, name string, all bool) bool {
 	kind := getKind(left, right)
 	compareFunc := compares[kind]
 	if compareFunc == nil {
 		panic("Cannot find compare func for:" + name + " Kind:" + kind.String())
 	}
+	list := reflect.ValueOf(left)
+	if list.Kind() == reflect.Slice {
+		for i := 0; i < list.Len(); i++ {
+			match := compareFunc(list.Index(i).Interface(), right)
+			if match != all {
+				return match
+			}
+		}
+		return all
+	}
 	return compareFunc(left, right)
 }
 
 func removeSingleQuote(value string) string {
-	if strings.Contains(value, "'") {
+	if len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'' {
 		return value[1 : len(value)-1]
 	}
 	return value
@@ -59,17 +112,22 @@ func eqStringMatcher(left, right interface{}) bool {
 		return false
 	}
 	aside := removeSingleQuote(strings.ToLower(left.(string)))
-	zside := removeSingleQuote(strings.ToLower(right.(string)))
+	zside, splits := parseLiteral(strings.ToLower(right.(string)))
+	return eqString(aside, zside, splits, IsNil(right.(string)))
+}
+
+// eqString compares a value to a literal, the splits are the substrings between the wildcards of the
+// literal and isNil is true for the nil keyword, which equals an empty value.
+func eqString(aside, zside string, splits []string, isNil bool) bool {
 	if aside == "nil" && zside == "" {
 		return true
 	}
-	if zside == "nil" && aside == "" {
+	if isNil && aside == "" {
 		return true
 	}
-	if aside == "*" || zside == "*" {
+	if aside == "*" || zside == "*" && splits != nil {
 		return true
 	}
-	splits := GetWildCardSubstrings(zside)
 	if splits == nil {
 		return aside == zside
 	}
@@ -112,6 +170,29 @@ func eqIntMatcher(left, right interface{}) bool {
 	return aside == zside
 }
 
+func eqBoolMatcher(left, right interface{}) bool {
+	aside, ok := getBool(left)
+	if !ok {
+		return false
+	}
+	zside, ok := getBool(right)
+	if !ok {
+		return false
+	}
+	return aside == zside
+}
+
+func getBool(v interface{}) (bool, bool) {
+	if b, ok := v.(bool); ok {
+		return b, true
+	}
+	b, e := strconv.ParseBool(removeSingleQuote(strings.ToLower(v.(string))))
+	if e != nil {
+		return false, false
+	}
+	return b, true
+}
+
 func eqUintMatcher(left, right interface{}) bool {
 	aside, ok := getUint64(left)
 	if !ok {
@@ -168,6 +249,9 @@ func getKind(aside, zside interface{}) reflect.Kind {
 func getInt64(v interface{}) (int64, bool) {
 	value := reflect.ValueOf(v)
 	if value.Kind() != reflect.String {
+		if !value.CanInt() {
+			return 0, false
+		}
 		return value.Int(), true
 	} else {
 		i, e := strconv.Atoi(value.String())
@@ -181,6 +265,9 @@ func getInt64(v interface{}) (int64, bool) {
 func getUint64(v interface{}) (uint64, bool) {
 	value := reflect.ValueOf(v)
 	if value.Kind() != reflect.String {
+		if !value.CanUint() {
+			return 0, false
+		}
 		return value.Uint(), true
 	} else {
 		i, e := strconv.Atoi(value.String())
@@ -190,10 +277,3 @@ func getUint64(v interface{}) (uint64, bool) {
 		return uint64(i), true
 	}
 }
-
-func GetWildCardSubstrings(str string) []string {
-	if !strings.Contains(str, "*") {
-		return nil
-	}
-	return strings.Split(str, "*")
-}
@@ -30,9 +30,14 @@ func (gt *GreaterThan) Compare(left, right interface{}) bool {
 	return Compare(left, right, gt.compares, "Greater Than")
 }
 
+// Compile returns a matcher of the kind for the literal, or nil if the kind is not supported.
+func (gt *GreaterThan) Compile(kind reflect.Kind, literal string) Matcher {
+	return compileOrder(kind, literal, func(c int) bool { return c > 0 })
+}
+
 func gtStringMatcher(left, right interface{}) bool {
 	aside := removeSingleQuote(strings.ToLower(left.(string)))
-	zside := removeSingleQuote(strings.ToLower(right.(string)))
+	zside := Unquote(strings.ToLower(right.(string)))
 	return aside > zside
 }
 
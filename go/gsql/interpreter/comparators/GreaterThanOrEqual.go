@@ -30,9 +30,14 @@ func (gteq *GreaterThanOrEqual) Compare(left, right interface{}) bool {
 	return Compare(left, right, gteq.compares, "Greater Than Or Equal")
 }
 
+// Compile returns a matcher of the kind for the literal, or nil if the kind is not supported.
+func (gteq *GreaterThanOrEqual) Compile(kind reflect.Kind, literal string) Matcher {
+	return compileOrder(kind, literal, func(c int) bool { return c >= 0 })
+}
+
 func gteqStringMatcher(left, right interface{}) bool {
 	aside := removeSingleQuote(strings.ToLower(left.(string)))
-	zside := removeSingleQuote(strings.ToLower(right.(string)))
+	zside := Unquote(strings.ToLower(right.(string)))
 	return aside >= zside
 }
 
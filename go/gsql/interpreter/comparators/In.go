@@ -31,6 +31,11 @@ func (in *IN) Compare(left, right interface{}) bool {
 	return Compare(left, right, in.compares, "In")
 }
 
+// Compile returns a matcher of the kind for the list literal, or nil if the kind is not supported.
+func (in *IN) Compile(kind reflect.Kind, literal string) Matcher {
+	return compileIn(kind, literal, false)
+}
+
 func inStringMatcher(left, right interface{}) bool {
 	aside := removeSingleQuote(strings.ToLower(left.(string)))
 	zsideList := strings.ToLower(right.(string))
@@ -88,11 +93,14 @@ func inUintMatcher(left, right interface{}) bool {
 func getInStringList(str string) []string {
 	index := strings.Index(str, "[")
 	index2 := strings.Index(str, "]")
+	if index == -1 || index2 <= index {
+		return []string{}
+	}
 	lst := str[index+1 : index2]
 	values := strings.Split(lst, ",")
 	result := make([]string, 0)
 	for _, v := range values {
-		result = append(result, removeSingleQuote(v))
+		result = append(result, Unquote(strings.TrimSpace(v)))
 	}
 	return result
 }
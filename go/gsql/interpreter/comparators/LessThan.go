@@ -30,9 +30,14 @@ func (lt *LessThan) Compare(left, right interface{}) bool {
 	return Compare(left, right, lt.compares, "Less Than")
 }
 
+// Compile returns a matcher of the kind for the literal, or nil if the kind is not supported.
+func (lt *LessThan) Compile(kind reflect.Kind, literal string) Matcher {
+	return compileOrder(kind, literal, func(c int) bool { return c < 0 })
+}
+
 func ltStringMatcher(left, right interface{}) bool {
 	aside := removeSingleQuote(strings.ToLower(left.(string)))
-	zside := removeSingleQuote(strings.ToLower(right.(string)))
+	zside := Unquote(strings.ToLower(right.(string)))
 	return aside < zside
 }
 
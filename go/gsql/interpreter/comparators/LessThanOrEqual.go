@@ -30,9 +30,14 @@ func (lteq *LessThanOrEqual) Compare(left, right interface{}) bool {
 	return Compare(left, right, lteq.compares, "Less Than Or Equal")
 }
 
+// Compile returns a matcher of the kind for the literal, or nil if the kind is not supported.
+func (lteq *LessThanOrEqual) Compile(kind reflect.Kind, literal string) Matcher {
+	return compileOrder(kind, literal, func(c int) bool { return c <= 0 })
+}
+
 func lteqStringMatcher(left, right interface{}) bool {
 	aside := removeSingleQuote(strings.ToLower(left.(string)))
-	zside := removeSingleQuote(strings.ToLower(right.(string)))
+	zside := Unquote(strings.ToLower(right.(string)))
 	return aside <= zside
 }
 
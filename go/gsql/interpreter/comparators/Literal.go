@@ -0,0 +1,61 @@
+package comparators
+
+import (
+	"strings"
+)
+
+// Unquote returns the value of a string literal, without its single quotes and with its escapes
+// undone, two quotes are a quote inside a quoted literal, \* is a * that is not a wildcard and \\ is a backslash.
+func Unquote(literal string) string {
+	value, _ := parseLiteral(literal)
+	return value
+}
+
+// GetWildCardSubstrings returns the substrings between the * wildcards of a literal, without its escapes,
+// or nil if the literal has no wildcard, e.g. an escaped \* of a bound value.
+func GetWildCardSubstrings(literal string) []string {
+	_, splits := parseLiteral(literal)
+	return splits
+}
+
+// UnquoteValue returns a value compared to a literal as the comparators compare it, without its
+// enclosing single quotes, a value has no escapes.
+func UnquoteValue(value string) string {
+	return removeSingleQuote(value)
+}
+
+// IsNil returns true if the literal is the nil keyword, a quoted 'nil' is the string nil.
+func IsNil(literal string) bool {
+	return strings.EqualFold(strings.TrimSpace(literal), "nil")
+}
+
+func parseLiteral(literal string) (string, []string) {
+	if len(literal) > 1 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		literal = strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+	if !strings.Contains(literal, "*") && !strings.Contains(literal, "\\") {
+		return literal, nil
+	}
+	value := strings.Builder{}
+	split := strings.Builder{}
+	var splits []string
+	for i := 0; i < len(literal); i++ {
+		c := literal[i]
+		switch {
+		case c == '\\' && i+1 < len(literal) && (literal[i+1] == '*' || literal[i+1] == '\\'):
+			i++
+			c = literal[i]
+		case c == '*':
+			splits = append(splits, split.String())
+			split.Reset()
+			value.WriteByte(c)
+			continue
+		}
+		value.WriteByte(c)
+		split.WriteByte(c)
+	}
+	if splits != nil {
+		splits = append(splits, split.String())
+	}
+	return value.String(), splits
+}
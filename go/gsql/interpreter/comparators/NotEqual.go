@@ -23,16 +23,37 @@ func NewNotEqual() *NotEqual {
 	c.compares[reflect.Uint16] = noteqUintMatcher
 	c.compares[reflect.Uint32] = noteqUintMatcher
 	c.compares[reflect.Uint64] = noteqUintMatcher
+	c.compares[reflect.Bool] = noteqBoolMatcher
 	return c
 }
 
 func (notequal *NotEqual) Compare(left, right interface{}) bool {
-	return Compare(left, right, notequal.compares, "Not Equal")
+	return CompareAll(left, right, notequal.compares, "Not Equal")
+}
+
+// Compile returns a matcher of the kind for the literal, or nil if the kind is not supported.
+func (notequal *NotEqual) Compile(kind reflect.Kind, literal string) Matcher {
+	if kind == reflect.Bool {
+		return compileBool(literal, func(equal bool) bool { return !equal })
+	}
+	return compileOrder(kind, literal, func(c int) bool { return c != 0 })
 }
 
 func noteqStringMatcher(left, right interface{}) bool {
 	aside := removeSingleQuote(strings.ToLower(left.(string)))
-	zside := removeSingleQuote(strings.ToLower(right.(string)))
+	zside := Unquote(strings.ToLower(right.(string)))
+	return aside != zside
+}
+
+func noteqBoolMatcher(left, right interface{}) bool {
+	aside, ok := getBool(left)
+	if !ok {
+		return false
+	}
+	zside, ok := getBool(right)
+	if !ok {
+		return false
+	}
 	return aside != zside
 }
 
@@ -28,7 +28,12 @@ func NewNotIN() *NotIN {
 }
 
 func (in *NotIN) Compare(left, right interface{}) bool {
-	return Compare(left, right, in.compares, "In")
+	return CompareAll(left, right, in.compares, "In")
+}
+
+// Compile returns a matcher of the kind for the list literal, or nil if the kind is not supported.
+func (in *NotIN) Compile(kind reflect.Kind, literal string) Matcher {
+	return compileIn(kind, literal, true)
 }
 
 func notinStringMatcher(left, right interface{}) bool {
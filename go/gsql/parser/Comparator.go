@@ -3,7 +3,7 @@ package parser
 import (
 	"bytes"
 	"errors"
-	"strings"
+	"sync"
 
 	"github.com/saichler/l8types/go/types/l8api"
 )
@@ -22,9 +22,10 @@ const (
 )
 
 var comparators = make([]ComparatorOperation, 0)
+var comparatorsOnce = sync.Once{}
 
 func initComparators() {
-	if len(comparators) == 0 {
+	comparatorsOnce.Do(func() {
 		comparators = append(comparators, GTEQ)
 		comparators = append(comparators, LTEQ)
 		comparators = append(comparators, Neq)
@@ -33,7 +34,7 @@ func initComparators() {
 		comparators = append(comparators, LT)
 		comparators = append(comparators, NOTIN)
 		comparators = append(comparators, IN)
-	}
+	})
 }
 
 func StringComparator(this *l8api.L8Comparator) string {
@@ -57,11 +58,11 @@ func VisualizeComparator(this *l8api.L8Comparator, lvl int) string {
 
 func NewCompare(ws string) (*l8api.L8Comparator, error) {
 	for _, op := range comparators {
-		loc := strings.Index(ws, string(op))
+		loc := indexOutsideKeys(ws, string(op))
 		if loc != -1 {
 			cmp := &l8api.L8Comparator{}
-			cmp.Left = strings.TrimSpace(strings.ToLower(ws[0:loc]))
-			cmp.Right = strings.TrimSpace(strings.ToLower(ws[loc+len(op):]))
+			cmp.Left = TrimAndLowerNoKeys(ws[0:loc])
+			cmp.Right = TrimAndLowerNoKeys(ws[loc+len(op):])
 			cmp.Oper = string(op)
 			if validateValue(cmp.Left) != "" {
 				return nil, errors.New(validateValue(cmp.Left))
@@ -76,8 +77,11 @@ func NewCompare(ws string) (*l8api.L8Comparator, error) {
 }
 
 func validateValue(ws string) string {
-	bo := strings.Index(ws, "(")
-	be := strings.Index(ws, ")")
+	if _, arg, ok := PathFunction(ws); ok {
+		ws = arg
+	}
+	bo := indexOutsideKeys(ws, "(")
+	be := indexOutsideKeys(ws, ")")
 	if bo != -1 || be != -1 {
 		return "Value " + ws + " contain illegale brackets."
 	}
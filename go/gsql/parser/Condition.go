@@ -53,12 +53,12 @@ func toString(this *l8api.L8Condition, buff *bytes.Buffer) {
 func NewCondition(ws string) (*l8api.L8Condition, error) {
 	loc := MAX_EXPRESSION_SIZE
 	var op ConditionOperation
-	and := strings.Index(ws, string(And))
+	and := indexOutsideKeys(ws, string(And))
 	if and != -1 {
 		loc = and
 		op = And
 	}
-	or := strings.Index(ws, string(Or))
+	or := indexOutsideKeys(ws, string(Or))
 	if or != -1 && or < loc {
 		loc = or
 		op = Or
@@ -96,13 +96,13 @@ func getLastConditionOp(ws string) (ConditionOperation, int, error) {
 	loc := -1
 	var op ConditionOperation
 
-	and := strings.LastIndex(ws, string(And))
+	and := lastIndexOutsideKeys(ws, string(And))
 	if and > loc {
 		op = And
 		loc = and
 	}
 
-	or := strings.LastIndex(ws, string(Or))
+	or := lastIndexOutsideKeys(ws, string(Or))
 	if or > loc {
 		op = Or
 		loc = or
@@ -117,12 +117,12 @@ func getLastConditionOp(ws string) (ConditionOperation, int, error) {
 func getFirstConditionOp(ws string) (ConditionOperation, int, error) {
 	loc := MAX_EXPRESSION_SIZE
 	var op ConditionOperation
-	and := strings.Index(ws, string(And))
+	and := indexOutsideKeys(ws, string(And))
 	if and != -1 {
 		loc = and
 		op = And
 	}
-	or := strings.Index(ws, string(Or))
+	or := indexOutsideKeys(ws, string(Or))
 	if or != -1 && or < loc {
 		loc = or
 		op = Or
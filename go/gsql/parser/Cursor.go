@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"strings"
+)
+
+// After is the keyset pagination clause, "after <cursor>", with the cursor of the last row of the previous page.
+const After = "after"
+
+// StripAfter returns the query text without the after clause and the cursor of the clause,
+// or the text as is and an empty cursor if the text has no after clause. The after keyword is a clause
+// only in clause position, after the from, where, sort-by, limit... clauses and followed by a hex cursor,
+// so a field or a value named after, e.g. where after=5, is not a clause.
+func StripAfter(sql string) (string, string) {
+	for index := indexOfWord(sql, After, 0); index != -1; index = indexOfWord(sql, After, index+1) {
+		if !isClausePosition(sql[:index]) {
+			continue
+		}
+		rest := strings.TrimLeft(sql[index+len(After):], " \t\n")
+		cursor := rest
+		end := strings.IndexAny(rest, " \t\n")
+		if end != -1 {
+			cursor = rest[:end]
+			rest = rest[end:]
+		} else {
+			rest = ""
+		}
+		if !isCursor(cursor) || !isClauseStart(rest) {
+			continue
+		}
+		return strings.TrimSpace(strings.TrimSpace(sql[:index]) + rest), cursor
+	}
+	return sql, ""
+}
+
+// isClausePosition returns true if the text before a keyword ends a clause, i.e. it does not end
+// with an operator or with a keyword that expects an operand.
+func isClausePosition(before string) bool {
+	before = strings.TrimRight(before, " \t\n")
+	if before == "" || strings.ContainsRune("=<>!(,", rune(before[len(before)-1])) {
+		return false
+	}
+	word := before[strings.LastIndexAny(before, " \t\n(")+1:]
+	switch strings.ToLower(word) {
+	case Select, From, Where, SortBy, Limit, Page, "and", "or", "not", "in", After:
+		return false
+	}
+	return true
+}
+
+// isClauseStart returns true if the text is empty or starts with a clause keyword.
+func isClauseStart(rest string) bool {
+	rest = strings.ToLower(strings.TrimSpace(rest))
+	if rest == "" {
+		return true
+	}
+	for _, word := range words {
+		if indexOfWord(rest, word, 0) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isCursor returns true if the token is a hex cursor.
+func isCursor(token string) bool {
+	if token == "" {
+		return false
+	}
+	for i := 0; i < len(token); i++ {
+		c := token[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -129,12 +129,47 @@ func parseNoBrackets(ws string) (*l8api.L8Expression, error) {
 }
 
 func getBO(ws string) int {
-	return strings.Index(ws, "(")
+	offset := 0
+	for {
+		loc := indexOutsideKeys(ws[offset:], "(")
+		if loc == -1 {
+			return -1
+		}
+		loc += offset
+		if !isFunctionBracket(ws, loc) {
+			return loc
+		}
+		be, e := getBE(ws, loc)
+		if e != nil {
+			return loc
+		}
+		offset = be + 1
+	}
 }
 
 func getBE(ws string, bo int) (int, error) {
 	count := 0
+	keys := 0
+	var quote byte
 	for i := bo; i < len(ws); i++ {
+		if quote != 0 {
+			if ws[i] == quote {
+				quote = 0
+			}
+			continue
+		}
+		if ws[i] == '\'' || ws[i] == '`' || keys > 0 && ws[i] == '"' {
+			quote = ws[i]
+			continue
+		}
+		if byte(ws[i]) == byte('[') {
+			keys++
+		} else if byte(ws[i]) == byte(']') && keys > 0 {
+			keys--
+		}
+		if keys > 0 {
+			continue
+		}
 		if byte(ws[i]) == byte('(') {
 			count++
 		} else if byte(ws[i]) == byte(')') {
@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+// Format returns the canonical L8QL text of the query. Parsing the text of a query that was created
+// by the parser returns an identical query tree. Query trees that were built programmatically may be
+// in a shape the parser does not create, those are formatted with parentheses that keep their meaning,
+// so formatting the parsed text again returns the same text. Literals are quoted & escaped as needed,
+// so the parser never reads a literal as part of the query.
+func Format(query *l8api.L8Query) string {
+	buff := bytes.Buffer{}
+	buff.WriteString(Select)
+	buff.WriteString(" ")
+	if len(query.Properties) == 0 {
+		buff.WriteString("*")
+	} else {
+		for i, prop := range query.Properties {
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			buff.WriteString(TrimAndLowerNoKeys(prop))
+		}
+	}
+	buff.WriteString(" ")
+	buff.WriteString(From)
+	buff.WriteString(" ")
+	buff.WriteString(strings.ToLower(strings.TrimSpace(query.RootType)))
+	if query.Criteria != nil {
+		where := FormatExpression(query.Criteria)
+		if where != "" {
+			buff.WriteString(" ")
+			buff.WriteString(Where)
+			buff.WriteString(" ")
+			buff.WriteString(where)
+		}
+	}
+	if query.SortBy != "" {
+		buff.WriteString(" ")
+		buff.WriteString(SortBy)
+		buff.WriteString(" ")
+		buff.WriteString(TrimAndLowerNoKeys(query.SortBy))
+	}
+	if query.Descending {
+		buff.WriteString(" ")
+		buff.WriteString(Descending)
+	}
+	if query.Limit > 0 {
+		buff.WriteString(" ")
+		buff.WriteString(Limit)
+		buff.WriteString(" ")
+		buff.WriteString(strconv.Itoa(int(query.Limit)))
+	}
+	if query.Page > 0 {
+		buff.WriteString(" ")
+		buff.WriteString(Page)
+		buff.WriteString(" ")
+		buff.WriteString(strconv.Itoa(int(query.Page)))
+	}
+	if query.MatchCase {
+		buff.WriteString(" ")
+		buff.WriteString(MatchCase)
+	}
+	return buff.String()
+}
+
+// FormatExpression returns the canonical L8QL text of the where clause.
+func FormatExpression(expr *l8api.L8Expression) string {
+	buff := &bytes.Buffer{}
+	formatExpression(expr, buff)
+	return buff.String()
+}
+
+func formatExpression(expr *l8api.L8Expression, buff *bytes.Buffer) {
+	op := formatOperation(expr.AndOr)
+	if expr.Condition != nil && expr.Child != nil {
+		//Not created by the parser, the condition & the child are combined by the expression operation
+		buff.WriteString("(")
+		formatCondition(expr.Condition, buff)
+		buff.WriteString(")")
+		buff.WriteString(op)
+		buff.WriteString("(")
+		formatExpression(expr.Child, buff)
+		buff.WriteString(")")
+	} else if expr.Condition != nil {
+		formatCondition(expr.Condition, buff)
+	} else if expr.Child != nil {
+		buff.WriteString("(")
+		formatExpression(expr.Child, buff)
+		buff.WriteString(")")
+	}
+	if expr.Next == nil {
+		return
+	}
+	buff.WriteString(op)
+	//The parser merges a condition that follows a condition into one condition,
+	//so such a next expression is wrapped to keep the grouping.
+	if expr.Condition != nil && expr.Next.Condition != nil {
+		buff.WriteString("(")
+		formatExpression(expr.Next, buff)
+		buff.WriteString(")")
+		return
+	}
+	formatExpression(expr.Next, buff)
+}
+
+func formatCondition(cond *l8api.L8Condition, buff *bytes.Buffer) {
+	if cond.Comparator != nil {
+		formatComparator(cond.Comparator, buff)
+	}
+	if cond.Next != nil {
+		buff.WriteString(formatOperation(cond.Oper))
+		formatCondition(cond.Next, buff)
+	}
+}
+
+func formatComparator(cmp *l8api.L8Comparator, buff *bytes.Buffer) {
+	buff.WriteString(formatOperand(cmp.Left))
+	oper := strings.ToLower(strings.TrimSpace(cmp.Oper))
+	if oper == strings.TrimSpace(string(IN)) || oper == strings.TrimSpace(string(NOTIN)) {
+		buff.WriteString(" ")
+		buff.WriteString(oper)
+		buff.WriteString(" ")
+	} else {
+		buff.WriteString(oper)
+	}
+	buff.WriteString(formatOperand(cmp.Right))
+}
+
+// formatOperand writes a literal so the parser reads it back as the same literal, a bare value
+// that is not a name, e.g. jo* or a value with spaces, is quoted and its quotes are escaped,
+// and a name that is a keyword, e.g. limit, is quoted with backticks.
+func formatOperand(value string) string {
+	value = strings.TrimSpace(value)
+	switch OperandOf(value) {
+	case StringOperand:
+		inner := value[1 : len(value)-1]
+		if !strings.Contains(strings.ReplaceAll(inner, "''", ""), "'") {
+			return value
+		}
+		return "'" + strings.ReplaceAll(inner, "'", "''") + "'"
+	case ValueOperand:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	case IdentifierOperand:
+		value = TrimAndLowerNoKeys(value)
+		if isKeyword(value) {
+			return IdentifierQuote + value + IdentifierQuote
+		}
+		return value
+	}
+	return TrimAndLowerNoKeys(value)
+}
+
+// isKeyword returns true if the name is a keyword of the query, which cannot be a bare name.
+func isKeyword(name string) bool {
+	for _, word := range words {
+		if name == word {
+			return true
+		}
+	}
+	for _, word := range []ConditionOperation{And, Or} {
+		if name == strings.TrimSpace(string(word)) {
+			return true
+		}
+	}
+	return name == "in" || name == "not" || name == After
+}
+
+func formatOperation(op string) string {
+	if strings.TrimSpace(strings.ToLower(op)) == strings.TrimSpace(string(Or)) {
+		return string(Or)
+	}
+	return string(And)
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Operand is the type of a comparator operand. Identifiers are resolved as properties of the root type,
+// literals are compared as values.
+type Operand int
+
+const (
+	// IdentifierOperand is a bare name, e.g. name or address.city, in strict mode it must resolve.
+	IdentifierOperand Operand = iota
+	// QuotedIdentifierOperand is a backtick quoted name, e.g. `2fa`, it must always resolve.
+	QuotedIdentifierOperand
+	// StringOperand is a single quoted string, e.g. 'john'.
+	StringOperand
+	NumberOperand
+	// KeywordOperand is true, false or nil.
+	KeywordOperand
+	ListOperand
+	PlaceholderOperand
+	// ValueOperand is a bare value that cannot be a name, e.g. jo* or 2024-01-01.
+	ValueOperand
+)
+
+const IdentifierQuote = "`"
+
+var keywords = []string{"true", "false", "nil"}
+
+func OperandOf(value string) Operand {
+	value = strings.TrimSpace(value)
+	if _, ok := Placeholder(value); ok || value == PositionalParam {
+		return PlaceholderOperand
+	}
+	if len(value) > 1 && strings.HasPrefix(value, IdentifierQuote) && strings.HasSuffix(value, IdentifierQuote) {
+		return QuotedIdentifierOperand
+	}
+	if len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return StringOperand
+	}
+	if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+		return ListOperand
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil && startsAsNumber(value) {
+		return NumberOperand
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(value, keyword) {
+			return KeywordOperand
+		}
+	}
+	if isIdentifier(value) {
+		return IdentifierOperand
+	}
+	return ValueOperand
+}
+
+// Identifier returns the name of an identifier operand without the backtick quotes.
+func Identifier(value string) string {
+	value = strings.TrimSpace(value)
+	if OperandOf(value) == QuotedIdentifierOperand {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func isIdentifier(value string) bool {
+	if _, arg, ok := PathFunction(value); ok {
+		value = arg
+	}
+	elements, err := SplitPath(value)
+	if err != nil || len(elements) == 0 {
+		return false
+	}
+	for _, elem := range elements {
+		if elem.Name == "" {
+			return false
+		}
+		for i, c := range elem.Name {
+			if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// startsAsNumber returns true if the value starts with a digit, a sign or a dot, so names that parse
+// as a float, e.g. inf or nan, are not numbers.
+func startsAsNumber(value string) bool {
+	c := value[0]
+	return c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.'
+}
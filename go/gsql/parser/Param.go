@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+const (
+	PositionalParam = "?"
+	NumberedParam   = "$"
+	NamedParam      = ":"
+)
+
+// Placeholder returns the name of the bind variable if the value is a placeholder,
+// e.g. "1" for $1 and "name" for :name. A ? placeholder is numbered by the parser to $n.
+func Placeholder(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return "", false
+	}
+	name := value[1:]
+	switch value[0:1] {
+	case NumberedParam:
+		n, err := strconv.Atoi(name)
+		if err != nil || n < 1 {
+			return "", false
+		}
+		return name, true
+	case NamedParam:
+		for i, c := range name {
+			if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+				return "", false
+			}
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// numberPlaceholders replaces the ? placeholders with $n, by their order in the query.
+// Mixing ? with $n placeholders is not allowed as the numbers would be ambiguous.
+func numberPlaceholders(expr *l8api.L8Expression) error {
+	positional := 0
+	numbered := false
+	var walk func(*l8api.L8Expression) error
+	replace := func(value string) (string, error) {
+		if value == PositionalParam {
+			positional++
+			value = NumberedParam + strconv.Itoa(positional)
+		} else if strings.HasPrefix(value, NumberedParam) {
+			if _, ok := Placeholder(value); ok {
+				numbered = true
+			}
+		}
+		if numbered && positional > 0 {
+			return "", errors.New("Cannot mix ? placeholders with numbered placeholders")
+		}
+		return value, nil
+	}
+	walk = func(expr *l8api.L8Expression) error {
+		if expr == nil {
+			return nil
+		}
+		for cond := expr.Condition; cond != nil; cond = cond.Next {
+			if cond.Comparator == nil {
+				continue
+			}
+			var e error
+			cond.Comparator.Left, e = replace(cond.Comparator.Left)
+			if e != nil {
+				return e
+			}
+			cond.Comparator.Right, e = replace(cond.Comparator.Right)
+			if e != nil {
+				return e
+			}
+		}
+		e := walk(expr.Child)
+		if e != nil {
+			return e
+		}
+		return walk(expr.Next)
+	}
+	return walk(expr)
+}
+
+// Literal renders a go value as a comparator literal. Strings are quoted so their content,
+// e.g. " or ", is never interpreted as part of the query, and are compared exactly: a quote is
+// escaped as two quotes, a * as \* so it is not a wildcard, and a quoted 'nil' is not the nil keyword.
+// Slices are rendered as an "in" list.
+func Literal(value interface{}) (string, error) {
+	if value == nil {
+		return "nil", nil
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "nil", nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return Quote(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Slice, reflect.Array:
+		buff := bytes.Buffer{}
+		buff.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			elem, err := Literal(v.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			if strings.Contains(elem, ",") || strings.Contains(elem, "[") || strings.Contains(elem, "]") {
+				return "", errors.New("List value " + elem + " cannot contain , [ or ]")
+			}
+			if i > 0 {
+				buff.WriteString(",")
+			}
+			buff.WriteString(elem)
+		}
+		buff.WriteString("]")
+		return buff.String(), nil
+	}
+	return "", errors.New("Unsupported literal type " + v.Type().String())
+}
+
+// Quote renders a string as an exact string literal, see Literal.
+func Quote(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "*", "\\*")
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,301 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	KeysFunction   = "keys"
+	ValuesFunction = "values"
+)
+
+var pathFunctions = []string{KeysFunction, ValuesFunction}
+
+// PathKey is the content of a bracketed accessor, e.g. ['newone'], [3], [-1] or [1:3].
+type PathKey struct {
+	Text   string
+	Quoted bool
+}
+
+// PathElement is a single dotted segment of a property path with its optional accessor.
+type PathElement struct {
+	Name string
+	Key  *PathKey
+}
+
+// PathRange is the content of a [from:to] accessor, a missing bound is marked as not set.
+type PathRange struct {
+	From    int
+	To      int
+	HasFrom bool
+	HasTo   bool
+}
+
+// Index returns the key as a list index, a negative index counts from the end of the list.
+func (this *PathKey) Index() (int, bool) {
+	if this.Quoted {
+		return 0, false
+	}
+	index, err := strconv.Atoi(this.Text)
+	if err != nil {
+		return 0, false
+	}
+	return index, true
+}
+
+// Range returns the key as a list range, e.g. [1:3], [:2] or [-2:].
+func (this *PathKey) Range() (*PathRange, bool) {
+	if this.Quoted {
+		return nil, false
+	}
+	loc := strings.Index(this.Text, ":")
+	if loc == -1 {
+		return nil, false
+	}
+	rng := &PathRange{}
+	from := strings.TrimSpace(this.Text[0:loc])
+	to := strings.TrimSpace(this.Text[loc+1:])
+	var err error
+	if from != "" {
+		rng.From, err = strconv.Atoi(from)
+		if err != nil {
+			return nil, false
+		}
+		rng.HasFrom = true
+	}
+	if to != "" {
+		rng.To, err = strconv.Atoi(to)
+		if err != nil {
+			return nil, false
+		}
+		rng.HasTo = true
+	}
+	return rng, true
+}
+
+// Bounds returns the start & end of the range on a list of the given size. Negative bounds count from
+// the end of the list and bounds out of the list are clamped, so the result is always a valid slice range.
+func (this *PathRange) Bounds(size int) (int, int) {
+	from := 0
+	to := size
+	if this.HasFrom {
+		from = this.From
+	}
+	if this.HasTo {
+		to = this.To
+	}
+	if from < 0 {
+		from += size
+	}
+	if to < 0 {
+		to += size
+	}
+	from = max(0, min(from, size))
+	to = max(0, min(to, size))
+	if from > to {
+		from = to
+	}
+	return from, to
+}
+
+func (this *PathKey) String() string {
+	if this.Quoted {
+		return "'" + this.Text + "'"
+	}
+	return this.Text
+}
+
+func (this *PathElement) String() string {
+	if this.Key == nil {
+		return this.Name
+	}
+	return this.Name + "[" + this.Key.String() + "]"
+}
+
+// PathFunction returns the function name and argument if the value is in the form of keys(path) or values(path).
+func PathFunction(value string) (string, string, bool) {
+	value = strings.TrimSpace(value)
+	for _, fn := range pathFunctions {
+		if strings.HasPrefix(value, fn+"(") && strings.HasSuffix(value, ")") {
+			return fn, strings.TrimSpace(value[len(fn)+1 : len(value)-1]), true
+		}
+	}
+	return "", "", false
+}
+
+// IsAccessPath returns true if the value is a property path that uses key accessors or path functions,
+// i.e. a field name followed by a key in brackets, e.g. mymap['a'].myfield, and not any literal with a bracket.
+func IsAccessPath(value string) bool {
+	value = strings.TrimSpace(value)
+	if _, _, ok := PathFunction(value); ok {
+		return true
+	}
+	index := strings.Index(value, "[")
+	if index <= 0 || !strings.Contains(value[index:], "]") {
+		return false
+	}
+	for i := 0; i < index; i++ {
+		c := value[i]
+		if !(c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitPath splits a property path to its elements, ignoring dots inside brackets or quotes.
+func SplitPath(path string) ([]*PathElement, error) {
+	result := make([]*PathElement, 0)
+	name := bytes.Buffer{}
+	key := bytes.Buffer{}
+	var elem *PathElement
+	inKey := false
+	var quote rune
+	for _, c := range path {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				key.WriteRune(c)
+			}
+		case inKey && (c == '\'' || c == '"'):
+			if strings.TrimSpace(key.String()) != "" {
+				return nil, errors.New("Illegal quote inside key in path: " + path)
+			}
+			key.Reset()
+			quote = c
+			elem.Key.Quoted = true
+		case inKey && c == ']':
+			inKey = false
+			if elem.Key.Quoted {
+				elem.Key.Text = key.String()
+			} else {
+				elem.Key.Text = strings.TrimSpace(key.String())
+			}
+			if elem.Key.Text == "" && !elem.Key.Quoted {
+				return nil, errors.New("Empty key in path: " + path)
+			}
+		case inKey:
+			if elem.Key.Quoted && c != ' ' {
+				return nil, errors.New("Illegal characters after quoted key in path: " + path)
+			}
+			key.WriteRune(c)
+		case c == '[':
+			if elem != nil && elem.Key != nil || name.Len() == 0 && elem == nil {
+				return nil, errors.New("Illegal key position in path: " + path)
+			}
+			if elem == nil {
+				elem = &PathElement{Name: strings.TrimSpace(name.String())}
+			}
+			elem.Key = &PathKey{}
+			key.Reset()
+			inKey = true
+		case c == '.':
+			if elem == nil {
+				elem = &PathElement{Name: strings.TrimSpace(name.String())}
+			}
+			if elem.Name == "" {
+				return nil, errors.New("Empty element in path: " + path)
+			}
+			result = append(result, elem)
+			elem = nil
+			name.Reset()
+		default:
+			if elem != nil {
+				return nil, errors.New("Illegal characters after key in path: " + path)
+			}
+			name.WriteRune(c)
+		}
+	}
+	if inKey || quote != 0 {
+		return nil, errors.New("Missing close bracket in path: " + path)
+	}
+	if elem == nil {
+		elem = &PathElement{Name: strings.TrimSpace(name.String())}
+	}
+	if elem.Name == "" {
+		return nil, errors.New("Empty element in path: " + path)
+	}
+	result = append(result, elem)
+	return result, nil
+}
+
+// StripKeys returns the path without its accessors, e.g. a['x'].b becomes a.b
+func StripKeys(elements []*PathElement) string {
+	buff := bytes.Buffer{}
+	for i, elem := range elements {
+		if i > 0 {
+			buff.WriteString(".")
+		}
+		buff.WriteString(elem.Name)
+	}
+	return buff.String()
+}
+
+// indexOutsideKeys is like strings.Index but ignores matches inside brackets & quotes, the single
+// quotes of a string literal, the backticks of a quoted identifier and the double quotes of a key.
+func indexOutsideKeys(ws, substr string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(ws); i++ {
+		c := ws[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '`' || depth > 0 && c == '"' {
+			quote = c
+			continue
+		}
+		if c == '[' {
+			depth++
+			continue
+		}
+		if c == ']' && depth > 0 {
+			depth--
+			continue
+		}
+		if depth == 0 && strings.HasPrefix(ws[i:], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastIndexOutsideKeys is like strings.LastIndex but ignores matches inside brackets & quotes.
+func lastIndexOutsideKeys(ws, substr string) int {
+	last := -1
+	offset := 0
+	for {
+		loc := indexOutsideKeys(ws[offset:], substr)
+		if loc == -1 {
+			return last
+		}
+		last = offset + loc
+		offset = last + 1
+	}
+}
+
+// isFunctionBracket returns true if the bracket at the index opens a path function call.
+func isFunctionBracket(ws string, index int) bool {
+	for _, fn := range pathFunctions {
+		start := index - len(fn)
+		if start < 0 || ws[start:index] != fn {
+			continue
+		}
+		if start == 0 {
+			return true
+		}
+		prev := ws[start-1]
+		if prev == ' ' || prev == '(' || prev == '=' || prev == '<' || prev == '>' {
+			return true
+		}
+	}
+	return false
+}
@@ -37,6 +37,8 @@ const (
 	Limit      = "limit"
 	Page       = "page"
 	MatchCase  = "match-case"
+	Explain    = "explain"
+	Analyze    = "analyze"
 )
 
 var words = []string{Select, From, Where, SortBy, Descending, Ascending, Limit, Page, MatchCase}
@@ -53,17 +55,25 @@ func NewQuery(query string, log ifs.ILogger) (*PQuery, error) {
 	return cwql, e
 }
 
+// TrimAndLowerNoKeys trims and lowers the text, except for keys in brackets and quoted string literals.
 func TrimAndLowerNoKeys(sql string) string {
 	buff := bytes.Buffer{}
 	sql = strings.TrimSpace(sql)
 	keyOpen := false
+	var quote rune
 	for _, c := range sql {
-		if c == '[' {
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+		} else if !keyOpen && (c == '\'' || c == '`') {
+			quote = c
+		} else if c == '[' {
 			keyOpen = true
 		} else if c == ']' {
 			keyOpen = false
 		}
-		if !keyOpen {
+		if !keyOpen && quote != '\'' && c != '\'' {
 			buff.WriteString(strings.ToLower(string(c)))
 		} else {
 			buff.WriteString(string(c))
@@ -72,6 +82,64 @@ func TrimAndLowerNoKeys(sql string) string {
 	return buff.String()
 }
 
+// NormalizeText lowers the query text, except for keys, and collapses the white spaces that are
+// not inside quotes or keys, so queries that differ only in case or spacing have the same text.
+func NormalizeText(sql string) string {
+	sql = TrimAndLowerNoKeys(sql)
+	buff := bytes.Buffer{}
+	keys := 0
+	var quote rune
+	space := false
+	for _, c := range sql {
+		if quote == 0 && keys == 0 && (c == ' ' || c == '\t' || c == '\n' || c == '\r') {
+			space = true
+			continue
+		}
+		if space {
+			buff.WriteString(" ")
+			space = false
+		}
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+		} else if c == '\'' || c == '"' {
+			quote = c
+		} else if c == '[' {
+			keys++
+		} else if c == ']' && keys > 0 {
+			keys--
+		}
+		buff.WriteRune(c)
+	}
+	return buff.String()
+}
+
+// RootTypeOf returns the type name in the from clause of the query text, without parsing the query.
+func RootTypeOf(sql string) string {
+	return getTag(TrimAndLowerNoKeys(sql), From)
+}
+
+// StripExplain returns the query text without the explain prefix and whether the text had the prefix.
+func StripExplain(sql string) (string, bool) {
+	return stripPrefix(sql, Explain)
+}
+
+// StripAnalyze returns the query text without the analyze prefix, that follows the explain prefix,
+// and whether the text had the prefix.
+func StripAnalyze(sql string) (string, bool) {
+	return stripPrefix(sql, Analyze)
+}
+
+func stripPrefix(sql, word string) (string, bool) {
+	trimmed := strings.TrimSpace(sql)
+	if len(trimmed) > len(word) && strings.EqualFold(trimmed[:len(word)], word) &&
+		(trimmed[len(word)] == ' ' || trimmed[len(word)] == '\t' || trimmed[len(word)] == '\n') {
+		return strings.TrimSpace(trimmed[len(word):]), true
+	}
+	return sql, false
+}
+
 func (this *PQuery) split() *parsed {
 	sql := TrimAndLowerNoKeys(this.pquery.Text)
 	data := &parsed{}
@@ -88,15 +156,14 @@ func (this *PQuery) split() *parsed {
 }
 
 func getBoolTag(str, tag string) string {
-	index := strings.Index(str, tag)
-	if index != -1 {
+	if indexOfWord(str, tag, 0) != -1 {
 		return "true"
 	}
 	return "false"
 }
 
 func getTag(str, tag string) string {
-	index := strings.Index(str, tag)
+	index := indexOfWord(str, tag, 0)
 	if index == -1 {
 		return ""
 	}
@@ -104,8 +171,8 @@ func getTag(str, tag string) string {
 	index2 := len(str)
 	for _, t := range words {
 		if t != tag {
-			index3 := strings.Index(str, t)
-			if index3 > index && index3 < index2 {
+			index3 := indexOfWord(str, t, index)
+			if index3 != -1 && index3 < index2 {
 				index2 = index3
 			}
 		}
@@ -113,15 +180,37 @@ func getTag(str, tag string) string {
 	return strings.TrimSpace(str[index:index2])
 }
 
+// indexOfWord returns the index of the keyword in the text from the offset, a keyword is a whole word
+// outside of quotes & keys, e.g. limit is not found in mystring='limit 5' or in mystring=unlimited.
+func indexOfWord(str, word string, offset int) int {
+	for offset <= len(str) {
+		loc := indexOutsideKeys(str[offset:], word)
+		if loc == -1 {
+			return -1
+		}
+		loc += offset
+		end := loc + len(word)
+		if (loc == 0 || !isWordChar(str[loc-1])) && (end == len(str) || !isWordChar(str[end])) {
+			return loc
+		}
+		offset = loc + 1
+	}
+	return -1
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
 func getSplitTag(str, tag string) []string {
 	result := make([]string, 0)
 	data := getTag(str, tag)
 	if data == "" {
 		return result
 	}
-	split := strings.Split(data, ",")
+	split := splitOutsideKeys(data, ",")
 	for _, t := range split {
-		result = append(result, t)
+		result = append(result, strings.TrimSpace(t))
 	}
 	return result
 }
@@ -130,6 +219,9 @@ func (this *PQuery) init() error {
 	p := this.split()
 	this.pquery.Properties = make([]string, 0)
 	this.pquery.RootType = strings.TrimSpace(p.from_)
+	if fields := strings.Fields(this.pquery.RootType); len(fields) > 1 {
+		return this.log.Error("Unexpected ", fields[1], " after from ", fields[0], DidYouMean(fields[1], words))
+	}
 	for _, col := range p.select_ {
 		this.pquery.Properties = append(this.pquery.Properties, col)
 	}
@@ -138,34 +230,43 @@ func (this *PQuery) init() error {
 		if e != nil {
 			return e
 		}
+		e = numberPlaceholders(where)
+		if e != nil {
+			return e
+		}
 		this.pquery.Criteria = where
 	}
 	if p.limit_ != "" {
-		limit, e := strconv.Atoi(p.limit_)
-		if e != nil {
-			this.log.Error("Invalid limit:", p.limit_, ", setting limity to 10")
-			limit = 10
-		}
-		if limit >= 1000 {
-			return this.log.Error("Invalid limit: Limit is limited up to 1000 elements")
+		limit, e := strconv.ParseInt(p.limit_, 10, 32)
+		if e != nil || limit < 0 {
+			return this.log.Error("Invalid limit:", p.limit_, ", the limit must be a non negative number")
 		}
 		this.pquery.Limit = int32(limit)
 	}
 	if p.page_ != "" {
-		page, e := strconv.Atoi(p.page_)
+		page, e := strconv.ParseInt(p.page_, 10, 32)
 		if e != nil {
 			return this.log.Error("Invalid page:", p.page_, ":", e.Error())
 		}
+		if page < 0 {
+			return this.log.Error("Invalid page:", p.page_, ", the page cannot be negative")
+		}
 		this.pquery.Page = int32(page)
 	}
-	this.pquery.SortBy = p.sortby_
+	if p.sortby_ != "" {
+		keys, e := ParseSortBy(p.sortby_)
+		if e != nil {
+			return this.log.Error(e.Error())
+		}
+		this.pquery.SortBy = FormatSortBy(keys)
+	}
 	if p.descending_ == "true" {
 		this.pquery.Descending = true
 	}
 	if p.ascending_ == "true" {
 		this.pquery.Descending = false
 	}
-	if p.matchcase_ != "" {
+	if p.matchcase_ == "true" {
 		this.pquery.MatchCase = true
 	}
 	return nil
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	Asc     = "asc"
+	Desc    = "desc"
+	Nulls   = "nulls"
+	First   = "first"
+	Last    = "last"
+	Collate = "collate"
+)
+
+// NullsOrder is the position of the null values of a sort key.
+type NullsOrder int
+
+const (
+	// NullsDefault sorts the nulls as the lowest values, first when ascending and last when descending.
+	NullsDefault NullsOrder = iota
+	NullsFirst
+	NullsLast
+)
+
+// Collation is the order of the string values of a sort key.
+type Collation string
+
+const (
+	// CollationDefault compares ignoring case, or as binary if the query has match-case.
+	CollationDefault Collation = ""
+	CollationNoCase  Collation = "nocase"
+	CollationBinary  Collation = "binary"
+	// CollationNatural compares ignoring case with the digit runs compared as numbers, e.g. a2 before a10.
+	CollationNatural Collation = "natural"
+)
+
+// SortKey is a key of the sort-by clause, e.g. "age desc nulls last" or "name collate natural".
+// A key with no direction follows the descending/ascending flag of the query.
+type SortKey struct {
+	Property     string
+	Descending   bool
+	HasDirection bool
+	Nulls        NullsOrder
+	Collation    Collation
+}
+
+// ParseSortBy parses the sort-by clause, a comma separated list of keys, each key is a property with
+// an optional direction, nulls order and collation, e.g. "country asc, age desc nulls last, name collate natural".
+func ParseSortBy(sortBy string) ([]*SortKey, error) {
+	result := make([]*SortKey, 0)
+	for _, text := range splitOutsideKeys(strings.TrimSpace(sortBy), ",") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, errors.New("Empty sort key in " + sortBy)
+		}
+		words := make([]string, 0)
+		for _, word := range splitOutsideKeys(text, " ") {
+			if word != "" {
+				words = append(words, word)
+			}
+		}
+		key := &SortKey{Property: words[0]}
+		for i := 1; i < len(words); i++ {
+			word := strings.ToLower(words[i])
+			switch {
+			case word == Asc || word == Desc:
+				if key.HasDirection {
+					return nil, errors.New("Duplicate direction in sort key " + text)
+				}
+				key.HasDirection = true
+				key.Descending = word == Desc
+			case word == Nulls && i+1 < len(words) && (strings.ToLower(words[i+1]) == First || strings.ToLower(words[i+1]) == Last):
+				key.Nulls = NullsFirst
+				if strings.ToLower(words[i+1]) == Last {
+					key.Nulls = NullsLast
+				}
+				i++
+			case word == Collate && i+1 < len(words):
+				collation := Collation(strings.ToLower(words[i+1]))
+				if collation != CollationNoCase && collation != CollationBinary && collation != CollationNatural {
+					return nil, errors.New("Unknown collation " + words[i+1] +
+						DidYouMean(words[i+1], []string{string(CollationNoCase), string(CollationBinary), string(CollationNatural)}))
+				}
+				key.Collation = collation
+				i++
+			default:
+				return nil, errors.New("Unexpected " + words[i] + " in sort key " + text +
+					DidYouMean(words[i], []string{Asc, Desc, Nulls, Collate}))
+			}
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// FormatSortBy returns the canonical text of the sort keys.
+func FormatSortBy(keys []*SortKey) string {
+	texts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		texts = append(texts, key.String())
+	}
+	return strings.Join(texts, ",")
+}
+
+func (this *SortKey) String() string {
+	buff := strings.Builder{}
+	buff.WriteString(this.Property)
+	if this.HasDirection {
+		buff.WriteString(" ")
+		if this.Descending {
+			buff.WriteString(Desc)
+		} else {
+			buff.WriteString(Asc)
+		}
+	}
+	switch this.Nulls {
+	case NullsFirst:
+		buff.WriteString(" " + Nulls + " " + First)
+	case NullsLast:
+		buff.WriteString(" " + Nulls + " " + Last)
+	}
+	if this.Collation != CollationDefault {
+		buff.WriteString(" " + Collate + " " + string(this.Collation))
+	}
+	return buff.String()
+}
+
+// splitOutsideKeys is like strings.Split but ignores the separators inside brackets & quotes.
+func splitOutsideKeys(ws, sep string) []string {
+	result := make([]string, 0)
+	for {
+		index := indexOutsideKeys(ws, sep)
+		if index == -1 {
+			return append(result, ws)
+		}
+		result = append(result, ws[:index])
+		ws = ws[index+len(sep):]
+	}
+}
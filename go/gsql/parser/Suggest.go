@@ -0,0 +1,49 @@
+package parser
+
+import "strings"
+
+// Suggest returns the candidate that is the closest to the word, or an empty string
+// if no candidate is close enough to be a likely typo of the word.
+func Suggest(word string, candidates []string) string {
+	word = strings.ToLower(word)
+	best := ""
+	bestDistance := len(word)/3 + 1
+	for _, candidate := range candidates {
+		distance := levenshtein(word, strings.ToLower(candidate))
+		if distance > 0 && distance <= bestDistance && (best == "" || distance < bestDistance) {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// DidYouMean returns the suggestion as a message suffix, or an empty string if there is no suggestion.
+func DidYouMean(word string, candidates []string) string {
+	suggestion := Suggest(word, candidates)
+	if suggestion == "" {
+		return ""
+	}
+	return ", did you mean " + suggestion + "?"
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
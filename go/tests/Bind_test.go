@@ -0,0 +1,237 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter/comparators"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+func TestParsePlaceholders(t *testing.T) {
+	q, e := parser.NewQuery("select * from testproto where mystring=? and (myint32>? or mystring in ?)", Log)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	testExpression(q, "(mystring=$1) and ((myint32>$2 or mystring in $3))", t)
+
+	q, e = parser.NewQuery("select * from testproto where mystring=:Name or myint32=:age", Log)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	testExpression(q, "(mystring=:name or myint32=:age)", t)
+
+	_, e = parser.NewQuery("select * from testproto where mystring=? or myint32=$1", Log)
+	if e == nil {
+		Log.Fail(t, "Expected an error when mixing ? & $n placeholders")
+		return
+	}
+}
+
+func TestBindPositional(t *testing.T) {
+	q, _, e := createQuery("select * from testproto where mystring=? and myint32=?")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if len(q.Params()) != 2 {
+		Log.Fail(t, "Expected 2 params")
+		return
+	}
+	node := CreateTestModelInstance(1)
+	node.MyString = "hello or mystring=*"
+	node.MyInt32 = 31
+	if q.Match(node) {
+		Log.Fail(t, "Expected an unbound query not to match")
+		return
+	}
+	bound, e := q.Bind("hello or mystring=*", 31)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !bound.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+	other, e := q.Bind("hello", 31)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if other.Match(node) {
+		Log.Fail(t, "Expected the bound value not to be interpreted as a query")
+		return
+	}
+	if !bound.Match(node) {
+		Log.Fail(t, "Expected the first bound query to be unaffected")
+		return
+	}
+	if _, e = q.Bind("hello"); e == nil {
+		Log.Fail(t, "Expected an error on missing values")
+		return
+	}
+}
+
+func TestBindNumberedAndNamed(t *testing.T) {
+	node := CreateTestModelInstance(1)
+	node.MyString = "O'Brien"
+	node.MyInt32 = 7
+	q, _, e := createQuery("select * from testproto where myint32=$2 and mystring=$1")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	bound, e := q.Bind("O'Brien", 7)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !bound.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+	q, _, e = createQuery("select * from testproto where mystring in :names and myint32=:age")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	bound, e = q.Bind(map[string]interface{}{"names": []string{"john", "O'Brien"}, "age": 7})
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !bound.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+	if _, e = q.Bind(map[string]interface{}{"names": []string{"a,b"}, "age": 7}); e == nil {
+		Log.Fail(t, "Expected an error for a list value with a comma")
+		return
+	}
+}
+
+func TestBindExact(t *testing.T) {
+	q, _, e := createQuery("select * from testproto where mystring=?")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	for _, test := range []struct {
+		value    string
+		data     string
+		expected bool
+	}{
+		{"*", "hello", false},
+		{"*", "*", true},
+		{"ab*", "abc", false},
+		{"ab*", "ab*", true},
+		{"a\\*", "a\\*", true},
+		{"nil", "", false},
+		{"nil", "nil", true},
+		{"it's", "it's", true},
+		{"it''s", "it's", false},
+	} {
+		node := CreateTestModelInstance(1)
+		node.MyString = test.data
+		bound, e := q.Bind(test.value)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		if bound.Match(node) != test.expected {
+			Log.Fail(t, "Expected ", test.value, " = ", test.data, " to be ", test.expected)
+			return
+		}
+		in, _, e := createQuery("select * from testproto where mystring in ?")
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		bound, e = in.Bind([]string{"x", test.value})
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		if bound.Match(node) != (test.data == test.value) {
+			Log.Fail(t, "Expected ", test.data, " in [x,", test.value, "] to be ", test.data == test.value)
+			return
+		}
+	}
+	//A nil value is still the nil keyword
+	node := CreateTestModelInstance(1)
+	node.MyString = ""
+	bound, e := q.Bind(nil)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !bound.Match(node) {
+		Log.Fail(t, "Expected nil to match an empty string")
+		return
+	}
+}
+
+func TestBindNumberedGap(t *testing.T) {
+	node := CreateTestModelInstance(1)
+	node.MyString = "O'Brien"
+	node.MyInt32 = 7
+	q, _, e := createQuery("select * from testproto where mystring=$1 and myint32=$3")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	bound, e := q.Bind("O'Brien", "unused", 7)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !bound.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+	if _, e = q.Bind("O'Brien", 7); e == nil {
+		Log.Fail(t, "Expected an error when $3 has no value")
+		return
+	}
+}
+
+func TestBindScalarIn(t *testing.T) {
+	node := CreateTestModelInstance(1)
+	node.MyString = "x"
+	node.MyInt32 = 5
+	for _, test := range []struct {
+		query    string
+		value    interface{}
+		expected bool
+	}{
+		{"select * from testproto where mystring in ?", "x", true},
+		{"select * from testproto where mystring in ?", "y", false},
+		{"select * from testproto where mystring not in ?", "x", false},
+		{"select * from testproto where myint32 in ?", 5, true},
+		{"select * from testproto where myint32 not in ?", 6, true},
+		{"select * from testproto where myint32 in :v", map[string]interface{}{"v": 5}, true},
+	} {
+		q, _, e := createQuery(test.query)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		bound, e := q.Bind(test.value)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		if bound.Match(node) != test.expected {
+			Log.Fail(t, "Expected ", test.query, " with ", test.value, " to be ", test.expected)
+			return
+		}
+	}
+	//A list with no brackets has no items
+	if comparators.NewIN().Compare("x", "'x'") || !comparators.NewNotIN().Compare("x", "'x'") {
+		Log.Fail(t, "Expected no items in a list with no brackets")
+		return
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql"
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+func TestBuilder(t *testing.T) {
+	builder := gsql.Select("MyString", "MyInt32").From("TestProto").
+		Where(gsql.Eq("myint32", 30).Or(gsql.In("mystring", "US", "IL")).And(gsql.Neq("mystring", "x"))).
+		SortBy("myint32").Desc().Limit(50)
+	expected := "select mystring,myint32 from testproto where myint32=30 or mystring in ['US','IL'] and (mystring!='x') sort-by myint32 descending limit 50"
+	if builder.String() != expected {
+		Log.Fail(t, "Expected: ", expected, " but got: ", builder.String())
+		return
+	}
+	query, e := builder.Query()
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	_, res, _ := createQuery("select * from testproto")
+	q, e := interpreter.NewFromQuery(query, res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	node := CreateTestModelInstance(1)
+	node.MyString = "IL"
+	if !q.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+	node.MyString = "x"
+	node.MyInt32 = 30
+	if q.Match(node) {
+		Log.Fail(t, "Expected no match")
+		return
+	}
+}
+
+func TestBuilderGrouping(t *testing.T) {
+	_, res, _ := createQuery("select * from testproto")
+	node := CreateTestModelInstance(1)
+	node.MyInt32 = 5
+	//(false and true) or true is true, false and (true or true) is false
+	criteria := gsql.Eq("myint32", 1).And(gsql.Eq("myint32", 5)).Or(gsql.Gte("myint32", 5))
+	query, e := gsql.Select().From("testproto").Where(criteria).Query()
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	q, e := interpreter.NewFromQuery(query, res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !q.Match(node) {
+		Log.Fail(t, "Expected a match for ", query.Text)
+		return
+	}
+	reparsed, e := interpreter.NewQuery(query.Text, res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !reparsed.Match(node) {
+		Log.Fail(t, "Expected a match for the reparsed ", query.Text)
+		return
+	}
+	if _, e = gsql.Select().From("testproto").Where(gsql.In("mystring", "a,b")).Query(); e == nil {
+		Log.Fail(t, "Expected an error on a list value with a comma")
+		return
+	}
+	if _, e = gsql.Select().Where(gsql.Eq("mystring", "a")).Query(); e == nil {
+		Log.Fail(t, "Expected an error on a missing root type")
+		return
+	}
+}
+
+func TestBuilderExact(t *testing.T) {
+	_, res, _ := createQuery("select * from testproto")
+	query, e := gsql.Select().From("testproto").Where(gsql.Eq("mystring", "*").Or(gsql.Eq("mystring", "nil"))).Query()
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	q, e := interpreter.NewFromQuery(query, res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	node := CreateTestModelInstance(1)
+	node.MyString = ""
+	if q.Match(node) {
+		Log.Fail(t, "Expected * and nil to be compared as strings")
+		return
+	}
+	node.MyString = "*"
+	if !q.Match(node) {
+		Log.Fail(t, "Expected a match of *")
+		return
+	}
+}
@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func createCache(size int) *interpreter.QueryCache {
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	r.Introspector().Inspect(&testtypes.TestProto{})
+	return interpreter.NewQueryCache(size, r)
+}
+
+func TestNormalizeText(t *testing.T) {
+	a := parser.NormalizeText("Select  MyString fRom   TestProto where MyString2ModelMap['A  b'].mystring = 'x  y'")
+	b := parser.NormalizeText("select mystring from testproto  where mystring2modelmap['A  b'].mystring = 'x  y'")
+	if a != b {
+		Log.Fail(t, "Expected the same normalized text: ", a, " != ", b)
+		return
+	}
+	if parser.RootTypeOf(a) != "testproto" {
+		Log.Fail(t, "Unexpected root type ", parser.RootTypeOf(a))
+		return
+	}
+}
+
+func TestCacheHitAndMiss(t *testing.T) {
+	cache := createCache(10)
+	q1, e := cache.Query("select * from testproto where mystring='hello'")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	q2, e := cache.Query("SELECT *   FROM TestProto WHERE MyString='hello'")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q1.Criteria() != q2.Criteria() {
+		Log.Fail(t, "Expected the same compiled query")
+		return
+	}
+	if q1.Text() != "select * from testproto where mystring='hello'" || q2.Text() != "SELECT *   FROM TestProto WHERE MyString='hello'" {
+		Log.Fail(t, "Expected the text of the caller but got ", q1.Text(), " and ", q2.Text())
+		return
+	}
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Size != 1 {
+		Log.Fail(t, "Unexpected stats ", stats)
+		return
+	}
+	if _, e = cache.Query("select * from nosuchtype"); e == nil {
+		Log.Fail(t, "Expected an error")
+		return
+	}
+	if cache.Stats().Size != 1 {
+		Log.Fail(t, "Did not expect a failed query to be cached")
+		return
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	cache := createCache(2)
+	cache.Query("select * from testproto where mystring='a'")
+	cache.Query("select * from testproto where mystring='b'")
+	cache.Query("select * from testproto where mystring='a'")
+	cache.Query("select * from testproto where mystring='c'")
+	stats := cache.Stats()
+	if stats.Size != 2 || stats.Evictions != 1 {
+		Log.Fail(t, "Unexpected stats ", stats)
+		return
+	}
+	cache.Query("select * from testproto where mystring='a'")
+	if cache.Stats().Hits != 2 {
+		Log.Fail(t, "Expected the recently used query to stay in the cache")
+		return
+	}
+	cache.Query("select * from testproto where mystring='b'")
+	if cache.Stats().Misses != 4 {
+		Log.Fail(t, "Expected the least recently used query to be evicted")
+		return
+	}
+	cache.SetSize(1)
+	if cache.Stats().Size != 1 {
+		Log.Fail(t, "Expected the cache to shrink")
+		return
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	cache := createCache(10)
+	q1, _ := cache.Query("select * from testproto where mystring='a'")
+	cache.Invalidate("TestProto")
+	q2, _ := cache.Query("select * from testproto where mystring='a'")
+	if q1 == q2 {
+		Log.Fail(t, "Expected a new compiled query after invalidation")
+		return
+	}
+	cache.Inspect(&testtypes.TestProtoSub{})
+	if cache.Stats().Size != 0 {
+		Log.Fail(t, "Expected inspecting a new type to purge the cache")
+		return
+	}
+}
+
+func TestCacheDirectRegistration(t *testing.T) {
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	r.Introspector().Inspect(&testtypes.TestProto{})
+	cache := interpreter.NewQueryCache(10, r)
+	q1, e := cache.Query("select * from testproto where mystring='a'")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	q2, _ := cache.Query("select * from testproto where mystring='a'")
+	if q1 != q2 {
+		Log.Fail(t, "Expected the cached query")
+		return
+	}
+	//A nested type registered directly in the introspector, not through the cache
+	r.Introspector().Inspect(&testtypes.TestProtoSub{})
+	q2, _ = cache.Query("select * from testproto where mystring='a'")
+	if q1 == q2 || cache.Stats().Misses != 2 {
+		Log.Fail(t, "Expected registering a nested type to invalidate the cached query")
+		return
+	}
+	q1, _ = cache.Query("select * from testproto where mystring='a'")
+	if q1 != q2 {
+		Log.Fail(t, "Expected the query compiled after the registration to be cached")
+		return
+	}
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	cache := createCache(5)
+	texts := []string{"a", "b", "c", "d", "e", "f", "g"}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				q, e := cache.Query("select * from testproto where mystring='" + texts[(i+j)%len(texts)] + "'")
+				if e != nil || q == nil {
+					Log.Fail(t, "Expected a query")
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses != 2000 || stats.Size > 5 {
+		Log.Fail(t, "Unexpected stats ", stats)
+	}
+}
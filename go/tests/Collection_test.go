@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func TestCollection(t *testing.T) {
+	collection, e := interpreter.NewCollection[*testtypes.TestProto](cursorResources())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if e = collection.AddIndex("myint32", interpreter.OrderedIndex); e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	for i := 0; i < 100; i++ {
+		if e = collection.Put(cursorModel(i)); e != nil {
+			Log.Fail(t, e)
+			return
+		}
+	}
+	// a put with the same primary key replaces the element
+	replaced := cursorModel(5)
+	replaced.MyInt64 = 55
+	collection.Put(replaced)
+	if collection.Len() != 100 {
+		Log.Fail(t, "Expected 100 elements but got ", collection.Len())
+		return
+	}
+	node, ok := collection.Get("string-5")
+	if !ok || node.MyInt64 != 55 {
+		Log.Fail(t, "Expected the replaced element")
+		return
+	}
+
+	result, e := collection.Query("select * from testproto where myint32=5 sort-by mystring descending limit 3")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if len(result) != 3 || result[0].MyString != "string-95" || result[2].MyString != "string-75" {
+		Log.Fail(t, "Unexpected query result ", len(result))
+		return
+	}
+
+	snapshot := collection.Snapshot()
+	for i := 0; i < 100; i += 10 {
+		collection.Delete("string-" + strconv.Itoa(i+5))
+	}
+	collection.Put(cursorModel(105))
+	before, _ := snapshot.Query("select * from testproto where myint32=5")
+	after, _ := collection.Query("select * from testproto where myint32=5")
+	if len(before) != 10 || len(after) != 1 || after[0].MyString != "string-105" {
+		Log.Fail(t, "Expected 10 elements in the snapshot and 1 in the collection but got ", len(before), " and ", len(after))
+		return
+	}
+	if snapshot.Len() != 100 || collection.Len() != 91 {
+		Log.Fail(t, "Expected 100 elements in the snapshot and 91 in the collection but got ", snapshot.Len(), " and ", collection.Len())
+		return
+	}
+
+	_, e = collection.Query("select * from testprotosub")
+	if e == nil {
+		Log.Fail(t, "Expected an error for a query of another type")
+		return
+	}
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	noKey, _ := interpreter.NewCollection[*testtypes.TestProto](r)
+	if e = noKey.Put(cursorModel(1)); e == nil {
+		Log.Fail(t, "Expected an error for a type with no primary key")
+		return
+	}
+}
+
+func TestCollectionConcurrency(t *testing.T) {
+	collection, _ := interpreter.NewCollection[*testtypes.TestProto](cursorResources())
+	collection.AddIndex("myint32", interpreter.HashIndex)
+	wg := sync.WaitGroup{}
+	for w := 0; w < 4; w++ {
+		wg.Add(2)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				collection.Put(cursorModel(w*1000 + i))
+				if i%3 == 0 {
+					collection.Delete("string-" + strconv.Itoa(w*1000+i))
+				}
+			}
+		}(w)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				snapshot := collection.Snapshot()
+				count := snapshot.Len()
+				result, e := snapshot.Query("select * from testproto where myint32 in [1,2,3]")
+				if e != nil {
+					Log.Fail(t, e)
+					return
+				}
+				all, _ := snapshot.Query("select * from testproto")
+				if len(all) != count || len(result) > count {
+					Log.Fail(t, "Snapshot changed while it was read")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if collection.Len() != 4*(200-67) {
+		Log.Fail(t, "Expected ", 4*(200-67), " elements but got ", collection.Len())
+	}
+}
+
+func BenchmarkCollectionPutQuery(b *testing.B) {
+	collection, _ := interpreter.NewCollection[*testtypes.TestProto](cursorResources())
+	collection.AddIndex("myint32", interpreter.HashIndex)
+	for i := 0; i < 10000; i++ {
+		collection.Put(cursorModel(i))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		collection.Put(cursorModel(i % 10000))
+		collection.Query("select * from testproto where myint32=3 limit 10")
+	}
+}
@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter/comparators"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+type valueComparator interface {
+	Compare(interface{}, interface{}) bool
+}
+
+func TestCompareMixedKinds(t *testing.T) {
+	for name, comparator := range map[string]valueComparator{
+		"=":  comparators.NewEqual(),
+		"!=": comparators.NewNotEqual(),
+		">":  comparators.NewGreaterThan(),
+		">=": comparators.NewGreaterThanOrEqual(),
+		"<":  comparators.NewLessThan(),
+		"<=": comparators.NewLessThanOrEqual(),
+	} {
+		for _, values := range [][2]interface{}{
+			{int32(5), nil}, {nil, int64(5)}, {int64(5), 5.0}, {int32(5), true},
+			{uint32(5), nil}, {uint64(5), -1.5}, {uint8(5), int64(5)},
+		} {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						Log.Fail(t, "Unexpected panic of ", name, " with ", values[0], " and ", values[1], ": ", r)
+					}
+				}()
+				comparator.Compare(values[0], values[1])
+			}()
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func TestCompiledMatch(t *testing.T) {
+	_, res, _ := createQuery("select * from testproto")
+	fields := []string{"myint32", "mystring", "mybool", "mymodelslice.mystring", "mystring2modelmap['x'].myint32"}
+	operations := []string{"=", "!=", ">", "<", ">=", "<=", " in ", " not in "}
+	literals := []string{"1", "'1'", "nil", "-3", "abc", "'mystring*'", "*", "true", "'FALSE'", "[1,2,x,3]", "['mystring-1','b']", "[]"}
+	nodes := []*testtypes.TestProto{CreateTestModelInstance(0), CreateTestModelInstance(1), CreateTestModelInstance(2)}
+	nodes[1].MyBool = true
+	nodes[2].MyString = "NIL"
+	for _, field := range fields {
+		for _, operation := range operations {
+			for _, literal := range literals {
+				text := "select * from testproto where " + field + operation + literal
+				q, e := interpreter.NewQuery(text, res)
+				if e != nil {
+					continue
+				}
+				where := q.Criteria().(*interpreter.Expression)
+				for _, node := range nodes {
+					expected, compiled := interpretedMatch(where, node), compiledMatch(q, node)
+					if expected != compiled {
+						Log.Fail(t, "Expected ", expected, " for ", text, " but the compiled query returned ", compiled)
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// interpretedMatch & compiledMatch report a panic of the comparators as a result, so both are compared,
+// an error is a false match as with Query.Match
+func interpretedMatch(where *interpreter.Expression, node interface{}) (result string) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = "panic"
+		}
+	}()
+	if m, _ := where.Match(node); m {
+		return "true"
+	}
+	return "false"
+}
+
+func compiledMatch(q *interpreter.Query, node interface{}) (result string) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = "panic"
+		}
+	}()
+	if q.Match(node) {
+		return "true"
+	}
+	return "false"
+}
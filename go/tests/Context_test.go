@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+// countingContext is done after its Err was called a number of times
+type countingContext struct {
+	context.Context
+	calls int
+}
+
+func (this *countingContext) Err() error {
+	this.calls--
+	if this.calls < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestMatchCtx(t *testing.T) {
+	q, res, e := createQuery("select * from testproto where mymodelslice[0:].mystring='x' or myint32=1")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	node := CreateTestModelInstance(1)
+	if m, e := q.MatchCtx(context.Background(), node); e != nil || m != q.Match(node) {
+		Log.Fail(t, "Expected the same result as Match")
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, e = q.MatchCtx(ctx, node); e != context.Canceled {
+		Log.Fail(t, "Expected a cancelled match but got ", e)
+		return
+	}
+	//The context is checked while the path fans out over the list
+	if _, e = q.MatchCtx(&countingContext{Context: context.Background(), calls: 2}, node); e != context.Canceled {
+		Log.Fail(t, "Expected a match cancelled in the path but got ", e)
+		return
+	}
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	list := []interface{}{node, CreateTestModelInstance(2)}
+	if _, e = q.FilterCtx(ctx, list, false); e != context.DeadlineExceeded {
+		Log.Fail(t, "Expected an expired filter but got ", e)
+		return
+	}
+	result, e := q.FilterCtx(context.Background(), list, false)
+	if e != nil || len(result) != len(q.Filter(list, false)) {
+		Log.Fail(t, "Expected the same result as Filter")
+		return
+	}
+	//The query deadline
+	q, e = interpreter.NewQuery(q.String(), res, interpreter.Timeout(time.Nanosecond))
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	for i := 0; i < 10000; i++ {
+		list = append(list, CreateTestModelInstance(i))
+	}
+	if _, e = q.FilterCtx(context.Background(), list, false); e != context.DeadlineExceeded {
+		Log.Fail(t, "Expected the query deadline to expire but got ", e)
+		return
+	}
+}
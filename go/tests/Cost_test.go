@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+func TestReorder(t *testing.T) {
+	_, res, _ := createQuery("select * from testproto")
+	q, e := interpreter.NewQuery("select * from testproto where mymodelslice.mystring='a' and mystring2modelmap.mystring='b' and myint32=5",
+		res, interpreter.Reordered())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	text := q.String()
+	if !strings.HasSuffix(text, "where mymodelslice.mystring='a' and mystring2modelmap.mystring='b' and myint32=5") {
+		Log.Fail(t, "Expected the operands that can fail to keep their position but got ", text)
+		return
+	}
+	q, e = interpreter.NewQuery("select * from testproto where mymodelslice.mystring='a' and mystring!='b' and myint32=5",
+		res, interpreter.Reordered())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	text = q.String()
+	if !strings.HasSuffix(text, "where mymodelslice.mystring='a' and myint32=5 and mystring!='b'") {
+		Log.Fail(t, "Expected the operands after the operand that can fail to be reordered but got ", text)
+		return
+	}
+	//An or is decided by the first true operand, so the likely operand goes first
+	q, e = interpreter.NewQuery("select * from testproto where myint32=5 or mystring!='a'", res, interpreter.Reordered())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	text = q.String()
+	if !strings.HasSuffix(text, "where mystring!='a' or myint32=5") {
+		Log.Fail(t, "Expected the selective operand of the or to be evaluated first but got ", text)
+		return
+	}
+	plan := q.Explain()
+	if plan.Cost <= 0 || plan.Cost >= 4 {
+		Log.Fail(t, "Unexpected cost ", plan.Cost)
+		return
+	}
+}
+
+func TestReorderErrors(t *testing.T) {
+	_, res, _ := createQuery("select * from testproto")
+	//An invalid list key fails the match, even when the other operand of the or is true
+	q, e := interpreter.NewQuery("select * from testproto where mymodelslice['x'].mystring='a' or myint32=5", res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	reordered := q.Reorder()
+	if !strings.HasSuffix(reordered.String(), "where mymodelslice['x'].mystring='a' or myint32=5") {
+		Log.Fail(t, "Expected the operand that can fail to keep its position but got ", reordered.String())
+		return
+	}
+	node := createSliceInstance()
+	for _, v := range []int32{4, 5} {
+		node.MyInt32 = v
+		if q.Match(node) || reordered.Match(node) {
+			Log.Fail(t, "Expected no match for an operand that fails with ", v)
+			return
+		}
+	}
+}
+
+func TestReorderSemantics(t *testing.T) {
+	r := rand.New(rand.NewSource(13))
+	_, res, _ := createQuery("select * from testproto")
+	node := CreateTestModelInstance(1)
+	for i := 0; i < 300; i++ {
+		query := &l8api.L8Query{RootType: "testproto", Criteria: randomExpression(r, 0)}
+		q, e := interpreter.NewFromQuery(query, res)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		reordered := q.Reorder()
+		for v := int32(0); v < 10; v++ {
+			node.MyInt32 = v
+			if q.Match(node) != reordered.Match(node) {
+				Log.Fail(t, "Expected the same match result for ", q.String(), " reordered to ", reordered.String(), " with ", v)
+				return
+			}
+		}
+	}
+}
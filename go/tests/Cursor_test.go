@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func cursorResources() ifs.IResources {
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	r.Introspector().Inspect(&testtypes.TestProto{})
+	r.Introspector().Decorators().AddPrimaryKeyDecorator(&testtypes.TestProto{}, "MyString")
+	return r
+}
+
+func cursorModel(i int) *testtypes.TestProto {
+	node := CreateTestModelInstance(i)
+	node.MyString = "string-" + strconv.Itoa(i)
+	node.MyInt32 = int32(i % 10)
+	return node
+}
+
+func TestKeysetPage(t *testing.T) {
+	r := cursorResources()
+	list := make([]interface{}, 0)
+	for i := 0; i < 100; i++ {
+		list = append(list, cursorModel(i))
+	}
+	q, e := interpreter.NewQuery("select * from testproto where myint32 < 8 sort-by myint32 limit 7", r)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	seen := make(map[string]bool)
+	var last *testtypes.TestProto
+	for pages := 0; ; pages++ {
+		page, cursor, err := q.KeysetPage(list)
+		if err != nil {
+			Log.Fail(t, err)
+			return
+		}
+		for _, elem := range page {
+			node := elem.(*testtypes.TestProto)
+			if seen[node.MyString] {
+				Log.Fail(t, "Duplicate row ", node.MyString, " in page ", pages)
+				return
+			}
+			if last != nil && (node.MyInt32 < last.MyInt32 || node.MyInt32 == last.MyInt32 && node.MyString < last.MyString) {
+				Log.Fail(t, "Row ", node.MyString, " is out of order")
+				return
+			}
+			seen[node.MyString] = true
+			last = node
+		}
+		if cursor == "" {
+			break
+		}
+		// rows inserted before the cursor do not shift the next page
+		list = append([]interface{}{cursorModel(1000 + pages)}, list...)
+		q, err = interpreter.NewQuery("select * from testproto where myint32 < 8 sort-by myint32 limit 7 after "+cursor, r)
+		if err != nil {
+			Log.Fail(t, err)
+			return
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if i%10 < 8 && !seen["string-"+strconv.Itoa(i)] {
+			Log.Fail(t, "Missing row string-", i)
+			return
+		}
+	}
+}
+
+func TestKeysetCursor(t *testing.T) {
+	r := cursorResources()
+	list := make([]interface{}, 0)
+	for i := 0; i < 20; i++ {
+		list = append(list, cursorModel(i))
+	}
+	q, e := interpreter.NewQuery("select * from testproto sort-by myint32 descending limit 5", r, interpreter.CursorSecret([]byte("secret")))
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	_, cursor, e := q.KeysetPage(list)
+	if e != nil || cursor == "" {
+		Log.Fail(t, "Expected a cursor ", e)
+		return
+	}
+	_, e = q.After(cursor)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	tampered := cursor[:10] + "0" + cursor[11:]
+	if tampered == cursor {
+		tampered = cursor[:10] + "1" + cursor[11:]
+	}
+	_, e = q.After(tampered)
+	if e == nil {
+		Log.Fail(t, "Expected an error for a tampered cursor")
+		return
+	}
+	other, _ := interpreter.NewQuery("select * from testproto sort-by myint32 descending limit 5", r, interpreter.CursorSecret([]byte("other")))
+	_, e = other.After(cursor)
+	if e == nil {
+		Log.Fail(t, "Expected an error for a cursor signed with another secret")
+		return
+	}
+	other, _ = interpreter.NewQuery("select * from testproto sort-by myint32 limit 5", r, interpreter.CursorSecret([]byte("secret")))
+	_, e = other.After(cursor)
+	if e == nil {
+		Log.Fail(t, "Expected an error for a cursor of another query")
+		return
+	}
+	noKey, _, _ := createQuery("select * from testproto sort-by myint32 limit 5")
+	_, _, e = noKey.KeysetPage(list)
+	if e == nil {
+		Log.Fail(t, "Expected an error for a type with no primary key")
+		return
+	}
+}
+
+func TestKeysetAfterClause(t *testing.T) {
+	r := cursorResources()
+	list := make([]interface{}, 0)
+	for i := 0; i < 20; i++ {
+		list = append(list, cursorModel(i))
+	}
+	q, e := interpreter.NewQuery("select * from testproto sort-by myint32 limit 5", r)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	_, cursor, e := q.KeysetPage(list)
+	if e != nil || cursor == "" {
+		Log.Fail(t, "Expected a cursor ", e)
+		return
+	}
+	after, e := interpreter.NewQuery("select * from testproto sort-by myint32 limit 5 after "+cursor, r)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if after.Canonical() == q.Canonical() || after.Hash() == q.Hash() {
+		Log.Fail(t, "Expected the cursor in the canonical form")
+		return
+	}
+	resumed, _ := q.After(cursor)
+	if resumed.Canonical() != after.Canonical() {
+		Log.Fail(t, "Expected the same canonical form for the same cursor")
+		return
+	}
+	//after in operand position is not a clause
+	for _, text := range []string{
+		"select * from testproto where after=5",
+		"select * from testproto where myint32=5 and after=5",
+		"select * from testproto where mystring=after",
+		"select * from testproto where mystring='x after " + cursor + "'",
+	} {
+		sql, token := parser.StripAfter(text)
+		if sql != text || token != "" {
+			Log.Fail(t, "Expected no after clause in ", text)
+			return
+		}
+	}
+	for _, text := range []string{
+		"select * from testproto where mystring='after' sort-by myint32 limit 5 after " + cursor,
+		"select * from testproto where mystring='after' sort-by myint32 after " + cursor + " limit 5",
+	} {
+		sql, token := parser.StripAfter(text)
+		if token != cursor || strings.Contains(sql, cursor) {
+			Log.Fail(t, "Expected the after clause in ", text)
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+func TestExplain(t *testing.T) {
+	q, _, e := createQuery("explain select mystring from testproto where myint32=5 and (mystring='a' or mystring in [b,c]) sort-by myint32")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !q.IsExplain() {
+		Log.Fail(t, "Expected an explain query")
+		return
+	}
+	plan := q.Explain()
+	if math.Abs(plan.Selectivity-0.1*(1-0.9*0.8)) > 0.0001 {
+		Log.Fail(t, "Unexpected selectivity ", plan.Selectivity)
+		return
+	}
+	text := plan.String()
+	for _, expected := range []string{
+		"Columns: testproto.mystring",
+		"Sort By: testproto.myint32",
+		"Index: none",
+		"#1 testproto.myint32 = 5 using Equal/int32 (cost 2.00, selectivity 0.1000)",
+		"#2 testproto.mystring = 'a' using Equal/string",
+		"#3 testproto.mystring in [b,c] using IN/string (cost 2.00, selectivity 0.2000)",
+	} {
+		if !strings.Contains(text, expected) {
+			Log.Fail(t, "Expected the plan to contain ", expected, " but got:\n", text)
+			return
+		}
+	}
+	data, e := plan.JSON()
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	parsed := &interpreter.Plan{}
+	if e = json.Unmarshal(data, parsed); e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if parsed.String() != text {
+		Log.Fail(t, "Expected the same plan from the JSON")
+		return
+	}
+	q, _, e = createQuery("select * from testproto where myint32=?")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q.IsExplain() || q.Explain().Where.Children[0].Children[0].Right.String() != "$1" {
+		Log.Fail(t, "Expected a placeholder operand in ", q.Explain().String())
+		return
+	}
+}
+
+func TestExplainAnalyze(t *testing.T) {
+	q, _, e := createQuery("explain analyze select * from testproto where myint32<10 and (mystring='string-1' or mystring='string-2')")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !q.IsExplain() || !q.IsAnalyze() {
+		Log.Fail(t, "Expected an explain analyze query")
+		return
+	}
+	list := make([]interface{}, 0)
+	for i := 0; i < 100; i++ {
+		list = append(list, CreateTestModelInstance(i))
+	}
+	plan := q.Analyze(list)
+	if plan.Stats.Invocations != 100 || plan.Stats.True != 2 || plan.Stats.False != 98 {
+		Log.Fail(t, "Unexpected query statistics ", plan.Stats.String())
+		return
+	}
+	first := plan.Where.Children[0].Children[0]
+	if first.Order != 1 || first.Stats.Invocations != 100 || first.Stats.True != 10 || first.Stats.Errors != 0 {
+		Log.Fail(t, "Unexpected comparator statistics ", first.Stats.String())
+		return
+	}
+	if first.Stats.Time < first.Stats.GetTime+first.Stats.CompareTime {
+		Log.Fail(t, "Expected the comparator time to include the get & compare times")
+		return
+	}
+	if !strings.Contains(plan.String(), "Analyzed: invocations 100, true 2, false 98, errors 0") {
+		Log.Fail(t, "Unexpected plan:\n", plan.String())
+		return
+	}
+	if len(q.Filter(list, false)) != 2 {
+		Log.Fail(t, "Expected the same result from filter")
+		return
+	}
+
+	q, _, e = createQuery("select * from testproto where myint32=?")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	plan = q.Analyze(list[:5])
+	if plan.Stats.Errors != 5 || plan.Where.Children[0].Children[0].Stats.Errors != 5 {
+		Log.Fail(t, "Expected errors on an unbound placeholder")
+		return
+	}
+}
@@ -0,0 +1,376 @@
+package tests
+
+import (
+	"bytes"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+var formatLefts = []string{"mystring", "myint32", "mymodelslice.mystring", "mystring2modelmap['Key x'].mystring"}
+var formatOpers = []string{"=", "!=", ">", "<", ">=", "<=", " in ", " not in "}
+var formatRights = []string{"a", "'b c'", "5", "'*'", "$1", "'Ab''c'", "`limit`"}
+
+func randomComparator(r *rand.Rand) string {
+	oper := formatOpers[r.Intn(len(formatOpers))]
+	right := formatRights[r.Intn(len(formatRights))]
+	if oper == " in " || oper == " not in " {
+		right = "[1,'x y',z]"
+	}
+	return formatLefts[r.Intn(len(formatLefts))] + oper + right
+}
+
+func randomOperation(r *rand.Rand) string {
+	if r.Intn(2) == 0 {
+		return " and "
+	}
+	return " Or "
+}
+
+func randomWhere(r *rand.Rand, depth int) string {
+	buff := bytes.Buffer{}
+	items := 1 + r.Intn(3)
+	for i := 0; i < items; i++ {
+		if i > 0 {
+			buff.WriteString(randomOperation(r))
+		}
+		if depth < 3 && r.Intn(3) == 0 {
+			buff.WriteString("(")
+			buff.WriteString(randomWhere(r, depth+1))
+			buff.WriteString(")")
+			continue
+		}
+		comparators := 1 + r.Intn(3)
+		for j := 0; j < comparators; j++ {
+			if j > 0 {
+				buff.WriteString(randomOperation(r))
+			}
+			buff.WriteString(randomComparator(r))
+		}
+	}
+	return buff.String()
+}
+
+func randomQueryText(r *rand.Rand) string {
+	buff := bytes.Buffer{}
+	buff.WriteString("Select mystring,MyInt32 from TestProto")
+	if r.Intn(5) > 0 {
+		buff.WriteString(" where ")
+		buff.WriteString(randomWhere(r, 0))
+	}
+	if r.Intn(2) == 0 {
+		buff.WriteString(" sort-by myint32")
+	}
+	if r.Intn(2) == 0 {
+		buff.WriteString(" descending")
+	}
+	if r.Intn(2) == 0 {
+		buff.WriteString(" limit " + strconv.Itoa(1+r.Intn(100)))
+	}
+	if r.Intn(2) == 0 {
+		buff.WriteString(" page " + strconv.Itoa(1+r.Intn(10)))
+	}
+	if r.Intn(2) == 0 {
+		buff.WriteString(" match-case")
+	}
+	return buff.String()
+}
+
+func sameComparator(a, b *l8api.L8Comparator) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Left == b.Left && a.Oper == b.Oper && a.Right == b.Right
+}
+
+func sameCondition(a, b *l8api.L8Condition) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Oper == b.Oper && sameComparator(a.Comparator, b.Comparator) && sameCondition(a.Next, b.Next)
+}
+
+func sameExpression(a, b *l8api.L8Expression) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.AndOr == b.AndOr && sameCondition(a.Condition, b.Condition) &&
+		sameExpression(a.Child, b.Child) && sameExpression(a.Next, b.Next)
+}
+
+func sameQuery(a, b *l8api.L8Query) bool {
+	if len(a.Properties) != len(b.Properties) {
+		return false
+	}
+	for i := range a.Properties {
+		if a.Properties[i] != b.Properties[i] {
+			return false
+		}
+	}
+	return a.RootType == b.RootType && a.SortBy == b.SortBy && a.Descending == b.Descending &&
+		a.Limit == b.Limit && a.Page == b.Page && a.MatchCase == b.MatchCase &&
+		sameExpression(a.Criteria, b.Criteria)
+}
+
+func TestFormat(t *testing.T) {
+	q, e := parser.NewQuery("Select column1,column2 fRom table1 wHere (1=2 or 3  =  4) And (5!=6 or 8<9) or 10<=12 sort-by col1 page 7 limit 50 match-case descending", Log)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	expected := "select column1,column2 from table1 where (1=2 or 3=4) and (5!=6 or 8<9) or 10<=12 sort-by col1 descending limit 50 page 7 match-case"
+	if parser.Format(q.Query()) != expected {
+		Log.Fail(t, "Expected: ", expected)
+		Log.Fail(t, "But got : ", parser.Format(q.Query()))
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	for i := 0; i < 1000; i++ {
+		text := randomQueryText(r)
+		q1, e := parser.NewQuery(text, Log)
+		if e != nil {
+			Log.Fail(t, "Failed to parse ", text, ": ", e.Error())
+			return
+		}
+		formatted := parser.Format(q1.Query())
+		q2, e := parser.NewQuery(formatted, Log)
+		if e != nil {
+			Log.Fail(t, "Failed to parse formatted ", formatted, ": ", e.Error())
+			return
+		}
+		if !sameQuery(q1.Query(), q2.Query()) {
+			Log.Fail(t, "Expected an identical tree for ", text, " formatted as ", formatted)
+			return
+		}
+		if parser.Format(q2.Query()) != formatted {
+			Log.Fail(t, "Expected the same text for ", formatted, " but got ", parser.Format(q2.Query()))
+			return
+		}
+	}
+}
+
+func randomExpression(r *rand.Rand, depth int) *l8api.L8Expression {
+	expr := &l8api.L8Expression{}
+	if depth < 3 && r.Intn(3) == 0 {
+		expr.Child = randomExpression(r, depth+1)
+	} else {
+		expr.Condition = randomCondition(r)
+	}
+	if r.Intn(2) == 0 {
+		expr.AndOr = strings.ToLower(randomOperation(r))
+		expr.Next = randomExpression(r, depth+1)
+	}
+	return expr
+}
+
+func randomCondition(r *rand.Rand) *l8api.L8Condition {
+	cond := &l8api.L8Condition{}
+	cond.Comparator = &l8api.L8Comparator{Left: "myint32", Oper: formatOpers[r.Intn(6)], Right: strconv.Itoa(r.Intn(10))}
+	if r.Intn(2) == 0 {
+		cond.Oper = strings.ToLower(randomOperation(r))
+		cond.Next = randomCondition(r)
+	}
+	return cond
+}
+
+func TestFormatProgrammaticRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	_, res, _ := createQuery("select * from testproto")
+	node := CreateTestModelInstance(1)
+	for i := 0; i < 300; i++ {
+		query := &l8api.L8Query{RootType: "testproto", Criteria: randomExpression(r, 0)}
+		formatted := parser.Format(query)
+		q1, e := interpreter.NewFromQuery(query, res)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		q2, e := interpreter.NewQuery(formatted, res)
+		if e != nil {
+			Log.Fail(t, "Failed to parse formatted ", formatted, ": ", e.Error())
+			return
+		}
+		if q2.String() != formatted {
+			Log.Fail(t, "Expected the same text for ", formatted, " but got ", q2.String())
+			return
+		}
+		for v := int32(0); v < 10; v++ {
+			node.MyInt32 = v
+			if q1.Match(node) != q2.Match(node) {
+				Log.Fail(t, "Expected the same match result for ", formatted, " with ", v)
+				return
+			}
+		}
+	}
+}
+
+var formatPieces = []string{"a", "B", " ", "\t", "'", "''", "*", "\\", "(", ")", "=", "!=", "<", ">=", ",", "[", "]",
+	"\"", "`", " or ", " and ", " in ", " not in ", " limit 5", " page ", "sort-by", " descending", " after x",
+	"where", "from", "select", "match-case", "$1", ":x"}
+
+// randomString returns a string of pieces of the query syntax, to check that string literals are never parsed as a query.
+func randomString(r *rand.Rand) string {
+	buff := bytes.Buffer{}
+	for i := r.Intn(6); i >= 0; i-- {
+		buff.WriteString(formatPieces[r.Intn(len(formatPieces))])
+	}
+	return buff.String()
+}
+
+// randomOperand returns an operand in the form the parser reads it, so it is formatted & parsed as is.
+func randomOperand(r *rand.Rand) string {
+	switch r.Intn(6) {
+	case 0:
+		return formatLefts[r.Intn(len(formatLefts))]
+	case 1:
+		return strconv.Itoa(r.Intn(100) - 50)
+	case 2:
+		return parser.Quote(randomString(r))
+	case 3:
+		return "`" + []string{"limit", "page", "after", "and", "select"}[r.Intn(5)] + "`"
+	}
+	return "'" + strings.ReplaceAll(randomString(r), "'", "''") + "'"
+}
+
+func randomLiteralExpression(r *rand.Rand, depth int) *l8api.L8Expression {
+	expr := &l8api.L8Expression{}
+	if depth < 3 && r.Intn(3) == 0 {
+		expr.Child = randomLiteralExpression(r, depth+1)
+	}
+	if expr.Child == nil || r.Intn(4) == 0 {
+		expr.Condition = randomLiteralCondition(r)
+	}
+	if r.Intn(2) == 0 {
+		expr.AndOr = strings.ToLower(randomOperation(r))
+		expr.Next = randomLiteralExpression(r, depth+1)
+	}
+	return expr
+}
+
+func randomLiteralCondition(r *rand.Rand) *l8api.L8Condition {
+	cond := &l8api.L8Condition{}
+	cond.Comparator = &l8api.L8Comparator{Left: formatLefts[r.Intn(len(formatLefts))], Oper: formatOpers[r.Intn(6)], Right: randomOperand(r)}
+	if r.Intn(4) == 0 {
+		cond.Comparator.Left, cond.Comparator.Right = cond.Comparator.Right, cond.Comparator.Left
+	}
+	if r.Intn(2) == 0 {
+		cond.Oper = strings.ToLower(randomOperation(r))
+		cond.Next = randomLiteralCondition(r)
+	}
+	return cond
+}
+
+// comparatorsOf returns the comparators of the expression in the order they are formatted.
+func comparatorsOf(expr *l8api.L8Expression, result []*l8api.L8Comparator) []*l8api.L8Comparator {
+	if expr == nil {
+		return result
+	}
+	for cond := expr.Condition; cond != nil; cond = cond.Next {
+		result = append(result, cond.Comparator)
+	}
+	result = comparatorsOf(expr.Child, result)
+	return comparatorsOf(expr.Next, result)
+}
+
+func TestFormatLiteralRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(31))
+	for i := 0; i < 2000; i++ {
+		query := &l8api.L8Query{RootType: "testproto", Properties: []string{"mystring"}, Criteria: randomLiteralExpression(r, 0),
+			SortBy: "myint32", Limit: int32(r.Intn(10)), Page: int32(r.Intn(3)), Descending: r.Intn(2) == 0, MatchCase: r.Intn(2) == 0}
+		formatted := parser.Format(query)
+		q1, e := parser.NewQuery(formatted, Log)
+		if e != nil {
+			Log.Fail(t, "Failed to parse formatted ", formatted, ": ", e.Error())
+			return
+		}
+		expected := comparatorsOf(query.Criteria, nil)
+		parsed := comparatorsOf(q1.Query().Criteria, nil)
+		if len(expected) != len(parsed) {
+			Log.Fail(t, "Expected ", len(expected), " comparators but got ", len(parsed), " for ", formatted)
+			return
+		}
+		for j := range expected {
+			if !sameComparator(expected[j], parsed[j]) {
+				Log.Fail(t, "Expected ", parser.StringComparator(expected[j]), " but got ", parser.StringComparator(parsed[j]), " for ", formatted)
+				return
+			}
+		}
+		if q1.Query().Limit != query.Limit || q1.Query().Page != query.Page || q1.Query().SortBy != query.SortBy ||
+			q1.Query().Descending != query.Descending || q1.Query().MatchCase != query.MatchCase {
+			Log.Fail(t, "Expected the same clauses for ", formatted)
+			return
+		}
+		q2, e := parser.NewQuery(parser.Format(q1.Query()), Log)
+		if e != nil {
+			Log.Fail(t, "Failed to parse ", parser.Format(q1.Query()), ": ", e.Error())
+			return
+		}
+		if !sameQuery(q1.Query(), q2.Query()) {
+			Log.Fail(t, "Expected an identical tree for ", formatted)
+			return
+		}
+	}
+}
+
+func TestFormatQuotesValues(t *testing.T) {
+	query := &l8api.L8Query{RootType: "testproto", Criteria: &l8api.L8Expression{Condition: &l8api.L8Condition{
+		Comparator: &l8api.L8Comparator{Left: "mystring", Oper: "=", Right: "hello or mystring=*"}}}}
+	expected := "select * from testproto where mystring='hello or mystring=*'"
+	if parser.Format(query) != expected {
+		Log.Fail(t, "Expected ", expected, " but got ", parser.Format(query))
+		return
+	}
+	_, res, _ := createQuery("select * from testproto")
+	q, e := interpreter.NewQuery("select * from testproto where mystring=Jo* and myint32=1", res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q.String() != "select * from testproto where mystring='jo*' and myint32=1" {
+		Log.Fail(t, "Unexpected text ", q.String())
+		return
+	}
+	node := CreateTestModelInstance(1)
+	node.MyString = "Joe"
+	node.MyInt32 = 1
+	formatted, e := interpreter.NewQuery(q.String(), res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !q.Match(node) || !formatted.Match(node) {
+		Log.Fail(t, "Expected the quoted wildcard to match")
+		return
+	}
+}
+
+func TestQueryString(t *testing.T) {
+	q, _, e := createQuery("select MyString from testproto where mystring=? and (myint32>5 or myint32<2) sort-by myint32 limit 10")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	expected := "select mystring from testproto where mystring=$1 and (myint32>5 or myint32<2) sort-by myint32 limit 10"
+	if q.String() != expected {
+		Log.Fail(t, "Expected: ", expected, " but got: ", q.String())
+		return
+	}
+	bound, e := q.Bind("hello")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	expected = "select mystring from testproto where mystring='hello' and (myint32>5 or myint32<2) sort-by myint32 limit 10"
+	if bound.String() != expected {
+		Log.Fail(t, "Expected: ", expected, " but got: ", bound.String())
+		return
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+func hashOf(query string, t *testing.T) string {
+	q, _, e := createQuery(query)
+	if e != nil {
+		Log.Fail(t, e)
+		return ""
+	}
+	return q.Hash()
+}
+
+func TestHashCoversAllAttributes(t *testing.T) {
+	base := "select mystring from testproto where mystring='a' sort-by mystring"
+	others := []string{
+		"select myint32 from testproto where mystring='a' sort-by mystring",
+		"select mystring from testproto where mystring='a' sort-by mystring descending",
+		"select mystring from testproto where mystring='a' sort-by mystring limit 10",
+		"select mystring from testproto where mystring='a' sort-by mystring page 2",
+		"select mystring from testproto where mystring='a' sort-by mystring match-case",
+		"select mystring from testproto where mystring='b' sort-by mystring",
+		"select mystring from testproto where mystring='a' sort-by myint32",
+	}
+	h := hashOf(base, t)
+	for _, other := range others {
+		if hashOf(other, t) == h {
+			Log.Fail(t, "Expected a different hash for ", other)
+			return
+		}
+	}
+}
+
+func TestHashCanonicalWhere(t *testing.T) {
+	same := [][]string{
+		{"select * from testproto where mystring='a' and myint32=1",
+			"select * from testproto where myint32=1 and mystring='a'"},
+		{"select * from testproto where mystring='a' or (myint32=1 and mystring='b')",
+			"select * from testproto where (mystring='b' and myint32=1) or mystring='a'"},
+		{"select * from testproto where ((mystring='a'))",
+			"select * from testproto where mystring='A'"},
+		{"select * from testproto where myint32>5",
+			"select * from testproto where 5<myint32"},
+		{"select * from testproto where mystring in [b,a,b]",
+			"select * from testproto where mystring in ['a', 'b']"},
+		{"select mystring,myint32 from testproto",
+			"select myint32, mystring from testproto"},
+	}
+	for _, pair := range same {
+		if hashOf(pair[0], t) != hashOf(pair[1], t) {
+			Log.Fail(t, "Expected the same hash for ", pair[0], " and ", pair[1])
+			return
+		}
+	}
+	different := [][]string{
+		{"select * from testproto where mystring='a' and myint32=1 or mystring='b'",
+			"select * from testproto where (mystring='a' and myint32=1) or mystring='b'"},
+		{"select * from testproto where myint32>5",
+			"select * from testproto where myint32<5"},
+		{"select * from testproto where mystring=nil",
+			"select * from testproto where mystring='nil'"},
+	}
+	for _, pair := range different {
+		if hashOf(pair[0], t) == hashOf(pair[1], t) {
+			Log.Fail(t, "Expected a different hash for ", pair[0], " and ", pair[1])
+			return
+		}
+	}
+}
+
+func TestHashVersions(t *testing.T) {
+	q, _, e := createQuery("select * from testproto where mystring='a'")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q.Hash() != q.HashOf(interpreter.DEFAULT_HASH_VERSION) {
+		Log.Fail(t, "Expected the default version")
+		return
+	}
+	if len(q.HashOf(interpreter.HashV1)) != 32 || len(q.HashOf(interpreter.HashV2)) != 32 || len(q.HashOf(interpreter.HashV3)) != 64 {
+		Log.Fail(t, "Unexpected hash length")
+		return
+	}
+	if q.HashOf(interpreter.HashV1) == q.HashOf(interpreter.HashV2) {
+		Log.Fail(t, "Expected different hashes for different versions")
+		return
+	}
+}
+
+func TestHashResolvedSortKeys(t *testing.T) {
+	base := "select * from testproto sort-by mystring, myint32 desc"
+	h := hashOf(base, t)
+	for _, same := range []string{
+		"select * from testproto sort-by testproto.mystring,myint32 desc",
+		"select * from testproto sort-by MyString  asc ,  myint32   DESC",
+		"select * from testproto sort-by mystring nulls first collate nocase, myint32 desc nulls last",
+	} {
+		if hashOf(same, t) != h {
+			Log.Fail(t, "Expected the same hash for ", same)
+			return
+		}
+	}
+	for _, other := range []string{
+		"select * from testproto sort-by mystring desc, myint32 desc",
+		"select * from testproto sort-by mystring nulls last, myint32 desc",
+		"select * from testproto sort-by mystring collate natural, myint32 desc",
+		"select * from testproto sort-by myint32 desc, mystring",
+	} {
+		if hashOf(other, t) == h {
+			Log.Fail(t, "Expected a different hash for ", other)
+			return
+		}
+	}
+	b, _, _ := createQuery(base)
+	q, _, _ := createQuery("select * from testproto sort-by testproto.mystring,  myint32 desc")
+	if b.HashOf(interpreter.HashV1) != q.HashOf(interpreter.HashV1) {
+		Log.Fail(t, "Expected the same legacy hash for the resolved sort keys")
+		return
+	}
+}
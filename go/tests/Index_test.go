@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func indexModel(i int) *testtypes.TestProto {
+	node := CreateTestModelInstance(i)
+	node.MyString = "string-" + strconv.Itoa(i%50)
+	node.MyInt32 = int32(i % 100)
+	node.MyBool = i%2 == 0
+	return node
+}
+
+// checkIndexed compares the result of the collection to the result of filtering the list
+func checkIndexed(collection *interpreter.IndexedCollection, list []interface{}, query, index string, r ifs.IResources, t *testing.T) bool {
+	q, e := interpreter.NewQuery(query, r)
+	if e != nil {
+		Log.Fail(t, e)
+		return false
+	}
+	expected := q.Filter(list, false)
+	result := collection.Filter(q, false)
+	if len(result) != len(expected) {
+		Log.Fail(t, query, ": expected ", len(expected), " elements but got ", len(result))
+		return false
+	}
+	for i := range result {
+		if result[i] != expected[i] {
+			Log.Fail(t, query, ": element ", i, " is out of order")
+			return false
+		}
+	}
+	plan := collection.Explain(q)
+	if plan.Index != index {
+		Log.Fail(t, query, ": expected index ", index, " but got ", plan.Index)
+		return false
+	}
+	return true
+}
+
+func TestIndexedCollection(t *testing.T) {
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	r.Introspector().Inspect(&testtypes.TestProto{})
+	collection := interpreter.NewIndexedCollection("testproto", r)
+	list := make([]interface{}, 0)
+	for i := 0; i < 1000; i++ {
+		node := indexModel(i)
+		collection.Put(strconv.Itoa(i), node)
+		list = append(list, node)
+	}
+	if e := collection.AddIndex("mystring", interpreter.HashIndex); e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if e := collection.AddIndex("myint32", interpreter.OrderedIndex); e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if e := collection.AddIndex("mybool", interpreter.OrderedIndex); e == nil {
+		Log.Fail(t, "Expected an error for an ordered index of a bool")
+		return
+	}
+	if e := collection.AddIndex("mymodelslice.mystring", interpreter.HashIndex); e == nil {
+		Log.Fail(t, "Expected an error for an index of a property in a list")
+		return
+	}
+
+	queries := map[string]string{
+		"select * from testproto where mystring=string-7":                               "hash(@testproto.mystring=string-7)",
+		"select * from testproto where mystring in [string-7,string-9]":                 "hash(@testproto.mystringin[string-7,string-9])",
+		"select * from testproto where myint32>=90":                                     "ordered(@testproto.myint32>=90)",
+		"select * from testproto where myint32>10 and myint32<13":                       "and(ordered(@testproto.myint32>10),ordered(@testproto.myint32<13))",
+		"select * from testproto where mystring=string-7 and mybool=true":               "hash(@testproto.mystring=string-7)",
+		"select * from testproto where mystring=string-7 or myint32<2":                  "or(hash(@testproto.mystring=string-7),ordered(@testproto.myint32<2))",
+		"select * from testproto where mystring=string-7 or mybool=true":                "none",
+		"select * from testproto where mystring=string-* and myint32=5":                 "ordered(@testproto.myint32=5)",
+		"select * from testproto where myint32 in [1,2,x,3]":                            "ordered(@testproto.myint32in[1,2,3,x])",
+		"select * from testproto where (mystring=string-1 or myint32=2) and myint32<60": "and(or(hash(@testproto.mystring=string-1),ordered(@testproto.myint32=2)),ordered(@testproto.myint32<60))",
+	}
+	check := func() bool {
+		for query, index := range queries {
+			if !checkIndexed(collection, list, query, index, r, t) {
+				return false
+			}
+		}
+		return true
+	}
+	if !check() {
+		return
+	}
+
+	// updates & deletes are reflected by the indexes
+	for i := 0; i < 1000; i += 3 {
+		node := indexModel(i + 7)
+		collection.Put(strconv.Itoa(i), node)
+		list[i] = node
+	}
+	remaining := make([]interface{}, 0)
+	for i, elem := range list {
+		if i%4 == 0 {
+			if !collection.Delete(strconv.Itoa(i)) {
+				Log.Fail(t, "Expected element ", i, " to be deleted")
+				return
+			}
+			continue
+		}
+		remaining = append(remaining, elem)
+	}
+	list = remaining
+	if collection.Len() != len(list) {
+		Log.Fail(t, "Expected ", len(list), " elements but got ", collection.Len())
+		return
+	}
+	check()
+}
+
+func TestIndexEscapedLiterals(t *testing.T) {
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	r.Introspector().Inspect(&testtypes.TestProto{})
+	collection := interpreter.NewIndexedCollection("testproto", r)
+	list := make([]interface{}, 0)
+	for i, value := range []string{"o'brien", "a*b", "a\\b", "'quoted'", "plain"} {
+		node := indexModel(i)
+		node.MyString = value
+		collection.Put(strconv.Itoa(i), node)
+		list = append(list, node)
+	}
+	if e := collection.AddIndex("mystring", interpreter.HashIndex); e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	//The index unquotes the literals as the comparators do
+	for _, query := range []string{
+		"select * from testproto where mystring='o''brien'",
+		"select * from testproto where mystring='a\\*b'",
+		"select * from testproto where mystring='a\\\\b'",
+		"select * from testproto where mystring in ['o''brien','plain']",
+	} {
+		q, e := interpreter.NewQuery(query, r)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		if !strings.HasPrefix(collection.Explain(q).Index, "hash(") {
+			Log.Fail(t, query, ": expected the hash index but got ", collection.Explain(q).Index)
+			return
+		}
+		expected := q.Filter(list, false)
+		result := collection.Filter(q, false)
+		if len(expected) == 0 || len(result) != len(expected) || result[0] != expected[0] {
+			Log.Fail(t, query, ": expected ", len(expected), " elements but got ", len(result))
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+type limitResources struct {
+	ifs.IResources
+	policy *interpreter.LimitPolicy
+}
+
+func (this *limitResources) LimitPolicy() *interpreter.LimitPolicy {
+	return this.policy
+}
+
+func TestLimitParse(t *testing.T) {
+	for _, query := range []string{
+		"select * from testproto limit abc",
+		"select * from testproto limit -1",
+		"select * from testproto limit 10 page -2",
+	} {
+		if !checkQuery(query, true, t) {
+			return
+		}
+	}
+	q, _, e := createQuery("select * from testproto limit 5000")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q.Limit() != 5000 {
+		Log.Fail(t, "Expected limit 5000 but got ", q.Limit())
+		return
+	}
+}
+
+func TestLimitPolicy(t *testing.T) {
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	r.Introspector().Inspect(&testtypes.TestProto{})
+	policy := &interpreter.LimitPolicy{DefaultLimit: 100, MaxLimit: 1000}
+	for query, limit := range map[string]int32{
+		"select * from testproto":            100,
+		"select * from testproto limit 10":   10,
+		"select * from testproto limit 1000": 1000,
+		"select * from testproto limit 1001": -1,
+	} {
+		q, e := interpreter.NewQuery(query, r, interpreter.Limits(policy))
+		if limit == -1 {
+			if e == nil {
+				Log.Fail(t, "Expected an error for ", query)
+				return
+			}
+			continue
+		}
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		if q.Limit() != limit {
+			Log.Fail(t, "Expected limit ", limit, " for ", query, " but got ", q.Limit())
+			return
+		}
+	}
+
+	clamp := &limitResources{IResources: r, policy: &interpreter.LimitPolicy{MaxLimit: 1000, Overflow: interpreter.OverflowClamp}}
+	for query, limit := range map[string]int32{
+		"select * from testproto":            1000,
+		"select * from testproto limit 5000": 1000,
+		"select * from testproto limit 20":   20,
+	} {
+		q, e := interpreter.NewQuery(query, clamp)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		if q.Limit() != limit {
+			Log.Fail(t, "Expected limit ", limit, " for ", query, " but got ", q.Limit())
+			return
+		}
+	}
+}
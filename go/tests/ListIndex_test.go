@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/parser"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func createSliceInstance() *testtypes.TestProto {
+	node := CreateTestModelInstance(1)
+	node.MyModelSlice = []*testtypes.TestProtoSub{
+		{MyString: "first"},
+		{MyString: "second"},
+		{MyString: "third"},
+		{MyString: "last"},
+	}
+	return node
+}
+
+func TestPathRangeBounds(t *testing.T) {
+	check := func(key string, size, expFrom, expTo int) bool {
+		rng, ok := (&parser.PathKey{Text: key}).Range()
+		if !ok {
+			Log.Fail(t, "Expected a range for ", key)
+			return false
+		}
+		from, to := rng.Bounds(size)
+		if from != expFrom || to != expTo {
+			Log.Fail(t, "Unexpected bounds for ", key, ": ", from, ":", to)
+			return false
+		}
+		return true
+	}
+	if !check("1:3", 4, 1, 3) || !check(":2", 4, 0, 2) || !check("-2:", 4, 2, 4) ||
+		!check("2:10", 4, 2, 4) || !check("3:1", 4, 1, 1) || !check("-10:1", 4, 0, 1) {
+		return
+	}
+	if _, ok := (&parser.PathKey{Text: "1"}).Range(); ok {
+		Log.Fail(t, "Did not expect a range")
+	}
+}
+
+func TestListIndex(t *testing.T) {
+	node := createSliceInstance()
+	if !checkMatch("select * from testproto where mymodelslice[0].mystring='first'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[1].mystring='first'", node, false, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[-1].mystring='last'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[-4].mystring='first'", node, true, t) {
+		return
+	}
+}
+
+func TestListIndexOutOfRange(t *testing.T) {
+	node := createSliceInstance()
+	if !checkMatch("select * from testproto where mymodelslice[4].mystring='first'", node, false, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[-5].mystring='first'", node, false, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[4].mystring!='first'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice['x'].mystring='first'", node, false, t) {
+		return
+	}
+}
+
+func TestListRange(t *testing.T) {
+	node := createSliceInstance()
+	if !checkMatch("select * from testproto where mymodelslice[1:3].mystring='third'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[1:3].mystring='last'", node, false, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[-1:].mystring='last'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[2:100].mystring!='first'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mymodelslice[3:1].mystring=*", node, false, t) {
+		return
+	}
+}
+
+func TestSelectListIndex(t *testing.T) {
+	node := createSliceInstance()
+	q, _, e := createQuery("select mymodelslice[-1].mystring, mymodelslice[0:1].mystring from testproto where mystring=*")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	result := q.Filter([]interface{}{node}, true)
+	if len(result) != 1 {
+		Log.Fail(t, "Expected 1 result")
+		return
+	}
+	clone := result[0].(*testtypes.TestProto)
+	if len(clone.MyModelSlice) != 4 {
+		Log.Fail(t, "Expected the list to keep its size")
+		return
+	}
+	if clone.MyModelSlice[0].MyString != "first" || clone.MyModelSlice[3].MyString != "last" {
+		Log.Fail(t, "Expected the selected elements to be projected")
+		return
+	}
+	if clone.MyModelSlice[1] != nil || clone.MyModelSlice[2] != nil {
+		Log.Fail(t, "Expected the elements that were not selected to be empty")
+		return
+	}
+	if clone.MyString != "" {
+		Log.Fail(t, "Expected only the selected columns")
+		return
+	}
+}
+
+func TestSelectColumnsWithKeys(t *testing.T) {
+	q, e := parser.NewQuery("select mystring2modelmap['a,b'].mystring, mymodelslice[0:1].mystring,mystring from testproto", Log)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	columns := q.Query().Properties
+	if len(columns) != 3 || columns[0] != "mystring2modelmap['a,b'].mystring" ||
+		columns[1] != "mymodelslice[0:1].mystring" || columns[2] != "mystring" {
+		Log.Fail(t, "Expected the commas inside the keys not to split the columns but got ", columns)
+		return
+	}
+}
+
+func TestSortByListIndex(t *testing.T) {
+	node := createSliceInstance()
+	q, _, e := createQuery("select * from testproto sort-by mymodelslice[-1].mystring")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q.SortByValue(node) != "last" {
+		Log.Fail(t, "Expected sort value to be last but got ", q.SortByValue(node))
+		return
+	}
+	q, _, e = createQuery("select * from testproto sort-by mymodelslice[10].mystring")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q.SortByValue(node) != nil {
+		Log.Fail(t, "Expected no sort value for an index out of range")
+		return
+	}
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/ifs"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+// Severity is an enum, a map keyed by it is accessed by the name or the number of the key
+type Severity int32
+
+const (
+	Minor Severity = 1
+	Major Severity = 2
+)
+
+func (this Severity) String() string {
+	switch this {
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	}
+	return "unknown"
+}
+
+type SeverityCounts struct {
+	Name   string
+	Counts map[Severity]int32
+}
+
+func TestSplitPath(t *testing.T) {
+	elements, e := parser.SplitPath("mystring2modelmap['my.Key with space'].mystring")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if len(elements) != 2 {
+		Log.Fail(t, "Expected 2 elements but got ", len(elements))
+		return
+	}
+	if elements[0].Key == nil || elements[0].Key.Text != "my.Key with space" || !elements[0].Key.Quoted {
+		Log.Fail(t, "Expected a quoted key")
+		return
+	}
+	if parser.StripKeys(elements) != "mystring2modelmap.mystring" {
+		Log.Fail(t, "Unexpected stripped path ", parser.StripKeys(elements))
+		return
+	}
+	elements, e = parser.SplitPath("myint2modelmap[12]")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if elements[0].Key.Text != "12" || elements[0].Key.Quoted {
+		Log.Fail(t, "Expected an unquoted key")
+		return
+	}
+	for _, path := range []string{"mymap['x'", "mymap[]", "mymap['x']y", "[x].y", "a..b"} {
+		_, e = parser.SplitPath(path)
+		if e == nil {
+			Log.Fail(t, "Expected an error for ", path)
+			return
+		}
+	}
+}
+
+func TestParseKeyWithOperators(t *testing.T) {
+	q, e := parser.NewQuery("select * from testproto where MyString2ModelMap['A=b and (c)'].myString=x or mystring=y", Log)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	cmp := q.Query().Criteria.Condition.Comparator
+	if cmp.Left != "mystring2modelmap['A=b and (c)'].mystring" {
+		Log.Fail(t, "Unexpected left side ", cmp.Left)
+		return
+	}
+	if cmp.Right != "x" {
+		Log.Fail(t, "Unexpected right side ", cmp.Right)
+		return
+	}
+}
+
+func TestParsePathFunction(t *testing.T) {
+	q, e := parser.NewQuery("select * from testproto where (keys(MyString2ModelMap)=x and mystring=y) or values(mymodelslice)=z", Log)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	testExpression(q, "((keys(mystring2modelmap)=x and mystring=y)) or (values(mymodelslice)=z)", t)
+}
+
+func TestMapKeyAccess(t *testing.T) {
+	node := CreateTestModelInstance(1)
+	node.MyString2ModelMap["newone"] = &testtypes.TestProtoSub{MyString: "hello"}
+	node.MyString2ModelMap["my.Key with space"] = &testtypes.TestProtoSub{MyString: "world"}
+	if !checkMatch("select * from testproto where MyString2ModelMap['newone'].myString='hello'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where MyString2ModelMap[newone].myString='hello'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where MyString2ModelMap['newone'].myString='world'", node, false, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where MyString2ModelMap['my.Key with space'].myString='world'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where MyString2ModelMap['missing'].myString='hello'", node, false, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where MyString2ModelMap['missing'].myString!='hello'", node, true, t) {
+		return
+	}
+}
+
+func TestMapKeyFunction(t *testing.T) {
+	node := CreateTestModelInstance(1)
+	node.MyString2ModelMap["newone"] = &testtypes.TestProtoSub{MyString: "hello"}
+	if !checkMatch("select * from testproto where keys(MyString2ModelMap)='newone'", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where keys(MyString2ModelMap) in [other,newone]", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where keys(MyString2ModelMap)!='newone'", node, false, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where keys(MyString2ModelMap) not in [other,another]", node, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where (keys(MyString2ModelMap)='newone' and mystring='nomatch') or keys(MyString2ModelMap)=new*", node, true, t) {
+		return
+	}
+}
+
+func TestMapKeyInvalidPath(t *testing.T) {
+	if !checkQuery("select * from testproto where MyString2ModelMap['newone'].noSuchField='hello'", true, t) {
+		return
+	}
+	if !checkQuery("select * from testproto where keys(noSuchMap)='hello'", true, t) {
+		return
+	}
+}
+
+func TestMapEnumKey(t *testing.T) {
+	r, _ := CreateResources(25000, 2, ifs.Trace_Level)
+	r.Introspector().Inspect(&SeverityCounts{})
+	node := &SeverityCounts{Name: "a", Counts: map[Severity]int32{Minor: 3, Major: 7}}
+	for query, expected := range map[string]bool{
+		"select * from severitycounts where counts['major']=7":   true,
+		"select * from severitycounts where counts[Major]=7":     true,
+		"select * from severitycounts where counts[2]=7":         true,
+		"select * from severitycounts where counts['minor']=7":   false,
+		"select * from severitycounts where counts['unknown']=7": false,
+		"select * from severitycounts where keys(counts)=2":      true,
+	} {
+		q, e := interpreter.NewQuery(query, r)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		if q.Match(node) != expected {
+			Log.Fail(t, "Expected ", expected, " for ", query)
+			return
+		}
+	}
+}
+
+func TestIsAccessPath(t *testing.T) {
+	for value, expected := range map[string]bool{
+		"mystring2modelmap['a'].mystring": true,
+		"mymodelslice[0]":                 true,
+		"keys(mystring2modelmap)":         true,
+		"abc[":                            false,
+		"a b[1]":                          false,
+		"'a[1]'":                          false,
+		"[1]":                             false,
+		"5*[x]":                           false,
+	} {
+		if parser.IsAccessPath(value) != expected {
+			Log.Fail(t, "Expected ", expected, " for ", value)
+			return
+		}
+	}
+	//An unresolved bracketed value is compared as a literal unless the query is strict
+	_, res, _ := createQuery("select * from testproto")
+	if _, e := interpreter.NewQuery("select * from testproto where mystring=abc[1]", res, interpreter.Strict()); e == nil {
+		Log.Fail(t, "Expected an unknown field error in strict mode")
+		return
+	}
+	q, e := interpreter.NewQuery("select * from testproto where mystring=abc[1]", res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	node := CreateTestModelInstance(1)
+	node.MyString = "abc[1]"
+	if !q.Match(node) {
+		Log.Fail(t, "Expected the bracketed value to match as a literal")
+		return
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/types/l8api"
+)
+
+func checkOptimize(query string, form interpreter.NormalForm, expected string, t *testing.T) bool {
+	q, _, e := createQuery(query)
+	if e != nil {
+		Log.Fail(t, e)
+		return false
+	}
+	optimized, e := q.Optimize(form)
+	if e != nil {
+		Log.Fail(t, e)
+		return false
+	}
+	if optimized.String() != expected {
+		Log.Fail(t, "Expected: ", expected, " but got: ", optimized.String())
+		return false
+	}
+	return true
+}
+
+func TestOptimize(t *testing.T) {
+	tests := [][]string{
+		{"select * from testproto where ((myint32=1)) and (myint32=1 or 1=myint32)", "select * from testproto where myint32=1"},
+		{"select * from testproto where 1=2 or myint32=3", "select * from testproto where myint32=3"},
+		{"select * from testproto where 1=1 and (myint32=3 and mystring='x')", "select * from testproto where myint32=3 and mystring='x'"},
+		{"select * from testproto where 1=2 and myint32=3", "select * from testproto where 1=2"},
+		{"select * from testproto where myint32=1 and mystring='x' and myint32=2", "select * from testproto where 1=2"},
+		{"select * from testproto where myint32>5 and myint32<3", "select * from testproto where 1=2"},
+		{"select * from testproto where myint32>=5 and (mystring='a' and 5>myint32)", "select * from testproto where 1=2"},
+		{"select * from testproto where myint32>5 or myint32<=5", "select * from testproto"},
+		{"select * from testproto where myint32!=1 or myint32!=2", "select * from testproto"},
+		{"select * from testproto where mystring='a' and (mystring='a' or myint32=1)", "select * from testproto where mystring='a'"},
+		{"select * from testproto where mymodelslice.mystring='a' and mymodelslice.mystring='b'",
+			"select * from testproto where mymodelslice.mystring='a' and mymodelslice.mystring='b'"},
+	}
+	for _, test := range tests {
+		if !checkOptimize(test[0], interpreter.AsWritten, test[1], t) {
+			return
+		}
+	}
+	if !checkOptimize("select * from testproto where myint32=1 and (mystring='a' or mystring='b')", interpreter.DNF,
+		"select * from testproto where (myint32=1 and mystring='a') or (myint32=1 and mystring='b')", t) {
+		return
+	}
+	if !checkOptimize("select * from testproto where (myint32=1 and mystring='a') or mystring='b'", interpreter.CNF,
+		"select * from testproto where (myint32=1 or mystring='b') and (mystring='a' or mystring='b')", t) {
+		return
+	}
+	_, res, _ := createQuery("select * from testproto")
+	q, e := interpreter.NewQuery("select * from testproto where myint32=2 and 1=1", res, interpreter.Optimized(interpreter.AsWritten))
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if q.String() != "select * from testproto where myint32=2" {
+		Log.Fail(t, "Expected an optimized query but got ", q.String())
+		return
+	}
+}
+
+func TestOptimizeSemantics(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	_, res, _ := createQuery("select * from testproto")
+	node := CreateTestModelInstance(1)
+	forms := []interpreter.NormalForm{interpreter.AsWritten, interpreter.CNF, interpreter.DNF}
+	for i := 0; i < 300; i++ {
+		query := &l8api.L8Query{RootType: "testproto", Criteria: randomExpression(r, 0)}
+		q, e := interpreter.NewFromQuery(query, res)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		for _, form := range forms {
+			optimized, e := q.Optimize(form)
+			if e != nil {
+				Log.Fail(t, e)
+				return
+			}
+			for v := int32(0); v < 10; v++ {
+				node.MyInt32 = v
+				if q.Match(node) != optimized.Match(node) {
+					Log.Fail(t, "Expected the same match result for ", q.String(), " optimized to ", optimized.String(), " with ", v)
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestOptimizeNonIntegerLiteral(t *testing.T) {
+	node := CreateTestModelInstance(1)
+	for _, query := range []string{
+		"select * from testproto where myint32=5.5 or myint32!=5.5",
+		"select * from testproto where myint32>5.5 or myint32<=5.5",
+		"select * from testproto where myint64=1e3 or myint64!=1e3",
+	} {
+		q, _, e := createQuery(query)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		optimized, e := q.Optimize(interpreter.AsWritten)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		for _, v := range []int32{5, 6} {
+			node.MyInt32 = v
+			node.MyInt64 = int64(v)
+			if q.Match(node) != optimized.Match(node) {
+				Log.Fail(t, "Expected the same match result for ", query, " optimized to ", optimized.String(), " with ", v)
+				return
+			}
+		}
+	}
+}
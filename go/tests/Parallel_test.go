@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+func TestFilterParallel(t *testing.T) {
+	q, _, e := createQuery("select * from testproto where myint32>100 and mystring!='s500' or mystring='s7'")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	list := make([]interface{}, 0, 5000)
+	for i := 0; i < 5000; i++ {
+		node := CreateTestModelInstance(i)
+		node.MyInt32 = int32(i)
+		node.MyString = "s" + strconv.Itoa(i)
+		list = append(list, node)
+	}
+	expected := q.Filter(list, false)
+	result, e := q.FilterParallel(context.Background(), list, false, interpreter.Workers(8), interpreter.ChunkSize(100))
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if len(result) != len(expected) {
+		Log.Fail(t, "Expected ", len(expected), " elements but got ", len(result))
+		return
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			Log.Fail(t, "Expected the order of the list at ", i)
+			return
+		}
+	}
+	//The compiled query is matched concurrently
+	wg := sync.WaitGroup{}
+	mismatches := make(chan int, len(list))
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(list); i += 8 {
+				if q.Match(list[i]) != (i > 100 && i != 500) {
+					mismatches <- i
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(mismatches)
+	for i := range mismatches {
+		Log.Fail(t, "Unexpected match result for ", i)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, e = q.FilterParallel(ctx, list, false); e != context.Canceled {
+		Log.Fail(t, "Expected a cancelled filter but got ", e)
+		return
+	}
+}
+
+// TestConcurrentQuery matches one shared query from several goroutines, through the compiled predicates,
+// bound copies & Analyze, run it with go test -race to check that the query is not modified when it is used.
+func TestConcurrentQuery(t *testing.T) {
+	q, res, e := createQuery("select * from testproto where myint32>100 and mystring!='s500' or mymodelslice[0].mystring='s7'")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	prepared, e := interpreter.NewQuery("select * from testproto where myint32>$1 and mystring!=$2", res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	list := make([]interface{}, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		node := CreateTestModelInstance(i)
+		node.MyInt32 = int32(i)
+		node.MyString = "s" + strconv.Itoa(i)
+		list = append(list, node)
+	}
+	expected := len(q.Filter(list, false))
+	wg := sync.WaitGroup{}
+	failures := make(chan string, 64)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				if n := len(q.Filter(list, false)); n != expected {
+					failures <- "filter matched " + strconv.Itoa(n)
+				}
+				bound, err := prepared.Bind(w*100, "s"+strconv.Itoa(w*100+1))
+				if err != nil {
+					failures <- err.Error()
+					return
+				}
+				if n := len(bound.Filter(list, false)); n != 1000-w*100-2 {
+					failures <- "bound " + strconv.Itoa(w) + " matched " + strconv.Itoa(n)
+				}
+				plan := q.Analyze(list)
+				if plan.Stats.True != uint64(expected) {
+					failures <- "analyze matched " + strconv.FormatUint(plan.Stats.True, 10)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(failures)
+	for failure := range failures {
+		Log.Fail(t, "Unexpected concurrent result: ", failure)
+		return
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func TestPrimaryKeys(t *testing.T) {
+	r := cursorResources()
+	for query, expected := range map[string]string{
+		"select * from testproto where mystring='abc'":                               "abc",
+		"select * from testproto where mystring='abc' and myint32>5":                 "abc",
+		"select * from testproto where mystring in [a,b,a]":                          "a,b",
+		"select * from testproto where mystring in [a,b] or mystring='c'":            "a,b,c",
+		"select * from testproto where mystring in [a,b] and mystring in [b,c]":      "b",
+		"select * from testproto where (mystring='a' or mystring='b') and myint32=1": "a,b",
+		"select * from testproto where mystring='a' or myint32=1":                    "-",
+		"select * from testproto where myint32=1":                                    "-",
+		"select * from testproto where mystring=a*":                                  "-",
+		"select * from testproto where mystring!='a'":                                "-",
+		"select * from testproto where mymodelslice.mystring='a'":                    "-",
+		"select * from testproto":                                                    "-",
+	} {
+		q, e := interpreter.NewQuery(query, r)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		keys, ok := q.PrimaryKeys()
+		result := strings.Join(keys, ",")
+		if !ok {
+			result = "-"
+		}
+		if result != expected {
+			Log.Fail(t, query, ": expected keys ", expected, " but got ", result)
+			return
+		}
+		if ok && len(keys) == 1 && q.KeyOf() != keys[0] || (!ok || len(keys) != 1) && q.KeyOf() != "" {
+			Log.Fail(t, query, ": unexpected KeyOf ", q.KeyOf())
+			return
+		}
+	}
+	q, _, _ := createQuery("select * from testproto where mystring='abc'")
+	if _, ok := q.PrimaryKeys(); ok {
+		Log.Fail(t, "Expected no primary keys for a type with no primary key")
+		return
+	}
+}
+
+func TestPrimaryKeyLookup(t *testing.T) {
+	collection, _ := interpreter.NewCollection[*testtypes.TestProto](cursorResources())
+	for i := 0; i < 100; i++ {
+		node := cursorModel(i)
+		node.MyString = strings.ToUpper(node.MyString)
+		collection.Put(node)
+	}
+	query, e := interpreter.NewQuery("select * from testproto where mystring in [string-5,string-50,string-500] and myint32=0", cursorResources())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	snapshot := collection.Snapshot()
+	result, _ := snapshot.Execute(query)
+	if len(result) != 1 || result[0].MyString != "STRING-50" {
+		Log.Fail(t, "Expected STRING-50 but got ", len(result), " elements")
+		return
+	}
+	plan := snapshot.Explain(query)
+	if plan.Index != "primary(string-5,string-50,string-500)" {
+		Log.Fail(t, "Expected a primary key lookup but got ", plan.Index)
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	"github.com/saichler/l8ql/go/gsql/parser"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+)
+
+func checkSuggestion(query, suggestion string, t *testing.T) bool {
+	_, _, e := createQuery(query)
+	if e == nil {
+		Log.Fail(t, "Expected an error for ", query)
+		return false
+	}
+	if !strings.Contains(e.Error(), "did you mean "+suggestion+"?") {
+		Log.Fail(t, "Expected a suggestion of ", suggestion, " but got: ", e.Error())
+		return false
+	}
+	return true
+}
+
+func TestSuggestions(t *testing.T) {
+	if !checkSuggestion("select * from testproto wehre myint32=1", "where", t) {
+		return
+	}
+	if !checkSuggestion("select * from testproto where mystrng=john", "mystring", t) {
+		return
+	}
+	if !checkSuggestion("select * from testproto where mymodelslice.mystrin=john", "mymodelslice.mystring", t) {
+		return
+	}
+	if !checkSuggestion("select mystrin from testproto", "mystring", t) {
+		return
+	}
+	if !checkSuggestion("select * from testproto sort-by myint3", "myint32", t) {
+		return
+	}
+}
+
+func TestStrict(t *testing.T) {
+	_, res, _ := createQuery("select * from testproto")
+	invalid := []string{
+		"select * from testproto where myint32=myvalue",
+		"select * from testproto where myint32 in [1,x]",
+		"select * from testproto where mybool=maybe",
+		"select * from testproto where mymodelslice>5",
+		"select * from testproto where mystring2modelmap='x'",
+	}
+	for _, query := range invalid {
+		if _, e := interpreter.NewQuery(query, res); e != nil {
+			Log.Fail(t, "Expected no error without strict validation for ", query, ": ", e.Error())
+			return
+		}
+		if _, e := interpreter.NewQuery(query, res, interpreter.Strict()); e == nil {
+			Log.Fail(t, "Expected a strict validation error for ", query)
+			return
+		}
+	}
+	valid := []string{
+		"select * from testproto where myint32=5 and mystring='x'",
+		"select * from testproto where myint32 in [1,'2',3] or mybool=true",
+		"select * from testproto where mystring2modelmap['x'].mystring>'a'",
+		"select * from testproto where mymodelslice=nil",
+	}
+	for _, query := range valid {
+		if _, e := interpreter.NewQuery(query, res, interpreter.Strict()); e != nil {
+			Log.Fail(t, "Expected no error for ", query, ": ", e.Error())
+			return
+		}
+	}
+	q, e := interpreter.NewQuery("select * from testproto where myint32=?", res, interpreter.Strict())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if _, e = q.Bind("abc"); e == nil {
+		Log.Fail(t, "Expected a strict validation error on bind")
+		return
+	}
+	if _, e = q.Bind(5); e != nil {
+		Log.Fail(t, e)
+		return
+	}
+}
+
+func TestIdentifiersAndLiterals(t *testing.T) {
+	_, res, _ := createQuery("select * from testproto")
+	node := CreateTestModelInstance(1)
+	node.MyString = "mystring"
+	//A quoted string is a literal even if it is a field name
+	q, e := interpreter.NewQuery("select * from testproto where myint32=1 and mystring='MyString'", res, interpreter.Strict())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !q.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+	//A backtick quoted identifier is always a field
+	q, e = interpreter.NewQuery("select `mystring` from testproto where `myint32`=1 sort-by `myint32`", res)
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !q.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+	if _, e = interpreter.NewQuery("select * from testproto where `myint3`=1", res); e == nil ||
+		!strings.Contains(e.Error(), "did you mean myint32?") {
+		Log.Fail(t, "Expected an unknown field error with a suggestion")
+		return
+	}
+	//A bare identifier that does not resolve is a literal only when not strict
+	if _, e = interpreter.NewQuery("select * from testproto where mystrng=john", res, interpreter.Strict()); e == nil ||
+		!strings.Contains(e.Error(), "Unknown field mystrng") {
+		Log.Fail(t, "Expected an unknown field error")
+		return
+	}
+	if _, e = interpreter.NewQuery("select * from testproto where mystring=mystrng", res, interpreter.Strict()); e == nil ||
+		!strings.Contains(e.Error(), "did you mean mystring?") {
+		Log.Fail(t, "Expected an unknown field error for an unquoted value")
+		return
+	}
+	other := CreateTestModelInstance(2)
+	other.MyString = "mystrng"
+	if !checkMatch("select * from testproto where mystring=mystrng", other, true, t) {
+		return
+	}
+	if !checkMatch("select * from testproto where mystring='mystrng'", other, true, t) {
+		return
+	}
+	//Field names with digits resolve, and only a value that starts as a number is a number
+	other.MyInt32 = 32
+	other.MyInt64 = 32
+	if !checkMatch("select * from testproto where myint32=myint64", other, true, t) {
+		return
+	}
+	for value, expected := range map[string]parser.Operand{"32": parser.NumberOperand, "-1.5e3": parser.NumberOperand,
+		".5": parser.NumberOperand, "inf": parser.IdentifierOperand, "nan": parser.IdentifierOperand, "e10": parser.IdentifierOperand} {
+		if parser.OperandOf(value) != expected {
+			Log.Fail(t, "Unexpected operand kind of ", value)
+			return
+		}
+	}
+	if _, e = interpreter.NewQuery("select * from testproto where myint32=inf", res, interpreter.Strict()); e == nil {
+		Log.Fail(t, "Expected inf to be an unknown field and not a number")
+		return
+	}
+	//Both sides are fields
+	if !checkMatch("select * from testproto where `mystring`=mystring", node, true, t) {
+		return
+	}
+	//Values that cannot be identifiers are literals in strict mode
+	q, e = interpreter.NewQuery("select * from testproto where mystring=mystr* and myint32 in [1,2] and mybool=false", res, interpreter.Strict())
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !q.Match(node) {
+		Log.Fail(t, "Expected a match")
+		return
+	}
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func sortList(size int) []interface{} {
+	list := make([]interface{}, 0, size)
+	for i := 0; i < size; i++ {
+		node := CreateTestModelInstance(i)
+		node.MyInt32 = int32((i * 7919) % 1009)
+		list = append(list, node)
+	}
+	return list
+}
+
+// fullSort is the reference, a stable sort of the whole list by the sort values
+func fullSort(q *interpreter.Query, list []interface{}) []interface{} {
+	values := make([]int32, len(list))
+	indexes := make([]int, len(list))
+	for i, elem := range list {
+		values[i] = q.SortByValue(elem).(int32)
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := values[indexes[i]], values[indexes[j]]
+		if q.Descending() {
+			return a > b
+		}
+		return a < b
+	})
+	from := min(int(q.Page()*q.Limit()), len(list))
+	to := min(from+int(q.Limit()), len(list))
+	result := make([]interface{}, 0, to-from)
+	for _, index := range indexes[from:to] {
+		result = append(result, list[index])
+	}
+	return result
+}
+
+func TestSortAndPage(t *testing.T) {
+	list := sortList(3000)
+	for _, query := range []string{
+		"select * from testproto sort-by myint32 limit 10",
+		"select * from testproto sort-by myint32 descending limit 50 page 3",
+		"select * from testproto sort-by myint32 limit 100 page 20",
+		"select * from testproto sort-by myint32 limit 999 page 2",
+		"select * from testproto sort-by myint32 limit 20 page 200",
+	} {
+		q, _, e := createQuery(query)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		expected := fullSort(q, list)
+		result := q.SortAndPage(list)
+		if len(result) != len(expected) {
+			Log.Fail(t, "Expected ", len(expected), " elements but got ", len(result), " for ", query)
+			return
+		}
+		for i := range expected {
+			if result[i] != expected[i] {
+				Log.Fail(t, "Unexpected element at ", i, " for ", query)
+				return
+			}
+		}
+	}
+	q, _, _ := createQuery("select * from testproto limit 5 page 1")
+	result := q.SortAndPage(list)
+	if len(result) != 5 || result[0] != list[5] {
+		Log.Fail(t, "Expected the page of the list as is")
+		return
+	}
+	// (page+1)*limit overflows int32
+	for _, query := range []string{
+		"select * from testproto sort-by myint32 limit 10 page 2147483647",
+		"select * from testproto sort-by myint32 limit 2147483647 page 2147483647",
+		"select * from testproto sort-by myint32 limit 2147483647",
+	} {
+		q, _, e := createQuery(query)
+		if e != nil {
+			Log.Fail(t, e)
+			return
+		}
+		result = q.SortAndPage(list)
+		if q.Page() == 0 && len(result) != len(list) || q.Page() > 0 && len(result) != 0 {
+			Log.Fail(t, "Unexpected ", len(result), " elements for ", query)
+			return
+		}
+	}
+}
+
+// benchmarkSortAndPage compares the bounded heap to a full sort, i.e. the same query with no limit
+// and the page taken from the sorted list
+func benchmarkSortAndPage(b *testing.B, size int, topK bool) {
+	list := sortList(size)
+	query := "select * from testproto sort-by myint32"
+	if topK {
+		query += " limit 50 page 1"
+	}
+	q, _, e := createQuery(query)
+	if e != nil {
+		b.Fatal(e)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		page := q.SortAndPage(list)
+		if !topK {
+			page = page[50:100]
+		}
+	}
+}
+
+func BenchmarkSortAndPageTopK(b *testing.B) {
+	for _, size := range []int{1000, 100000} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			benchmarkSortAndPage(b, size, true)
+		})
+	}
+}
+
+func BenchmarkSortAndPageFullSort(b *testing.B) {
+	for _, size := range []int{1000, 100000} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			benchmarkSortAndPage(b, size, false)
+		})
+	}
+}
+
+func checkSort(query string, list []interface{}, expected []int32, t *testing.T) bool {
+	q, _, e := createQuery(query)
+	if e != nil {
+		Log.Fail(t, e)
+		return false
+	}
+	result := q.SortAndPage(list)
+	ids := make([]int32, 0, len(result))
+	for _, elem := range result {
+		ids = append(ids, elem.(*testtypes.TestProto).MyInt32)
+	}
+	for i := range expected {
+		if i >= len(ids) || ids[i] != expected[i] {
+			Log.Fail(t, "Expected ", expected, " but got ", ids, " for ", query)
+			return false
+		}
+	}
+	return true
+}
+
+func TestMultiKeySort(t *testing.T) {
+	list := make([]interface{}, 0)
+	for i, name := range []string{"b", "a10", "A2", "b", "a1", "a2"} {
+		node := CreateTestModelInstance(i)
+		node.MyInt32 = int32(i)
+		node.MyString = name
+		if i%2 == 0 {
+			node.MyModelSlice = nil
+		}
+		list = append(list, node)
+	}
+	if !checkSort("select * from testproto sort-by mystring, myint32 desc", list, []int32{4, 1, 5, 2, 3, 0}, t) {
+		return
+	}
+	if !checkSort("select * from testproto sort-by mystring desc, myint32 asc", list, []int32{0, 3, 2, 5, 1, 4}, t) {
+		return
+	}
+	if !checkSort("select * from testproto sort-by mystring collate natural, myint32", list, []int32{4, 2, 5, 1, 0, 3}, t) {
+		return
+	}
+	if !checkSort("select * from testproto sort-by mystring collate binary, myint32", list, []int32{2, 4, 1, 5, 0, 3}, t) {
+		return
+	}
+	//The global direction applies to the keys with no direction
+	if !checkSort("select * from testproto sort-by mystring asc, myint32 descending", list, []int32{4, 1, 5, 2, 3, 0}, t) {
+		return
+	}
+	//Nulls, the elements with no list have no value for the key
+	if !checkSort("select * from testproto sort-by mymodelslice[0].mystring nulls last, myint32", list, []int32{1, 3, 5, 0, 2, 4}, t) {
+		return
+	}
+	if !checkSort("select * from testproto sort-by mymodelslice[0].mystring desc, myint32", list, []int32{1, 3, 5, 0, 2, 4}, t) {
+		return
+	}
+	if !checkSort("select * from testproto sort-by mymodelslice[0].mystring desc nulls first, myint32", list, []int32{0, 2, 4, 1, 3, 5}, t) {
+		return
+	}
+	q, _, e := createQuery("select * from testproto sort-by mystring  ASC, myint32 desc nulls first collate natural")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	if !strings.Contains(q.String(), "sort-by mystring asc,myint32 desc nulls first collate natural") {
+		Log.Fail(t, "Unexpected sort-by text ", q.String())
+		return
+	}
+	if !checkSuggestion("select * from testproto sort-by myint32 collate natrual", "natural", t) {
+		return
+	}
+	if _, _, e = createQuery("select * from testproto sort-by myint32 up"); e == nil {
+		Log.Fail(t, "Expected an error for an unknown sort key option")
+		return
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func streamInput(size int, read *int) func(yield func(any) bool) {
+	return func(yield func(any) bool) {
+		for i := 0; i < size; i++ {
+			*read++
+			node := CreateTestModelInstance(i)
+			node.MyInt32 = int32(i)
+			if !yield(node) {
+				return
+			}
+		}
+	}
+}
+
+func checkStream(query string, expected []int32, expectedRead int, t *testing.T) bool {
+	q, _, e := createQuery(query)
+	if e != nil {
+		Log.Fail(t, e)
+		return false
+	}
+	read := 0
+	result := make([]int32, 0)
+	for elem := range q.Stream(streamInput(100, &read)) {
+		result = append(result, elem.(*testtypes.TestProto).MyInt32)
+	}
+	if len(result) != len(expected) {
+		Log.Fail(t, "Expected ", expected, " but got ", result, " for ", query)
+		return false
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			Log.Fail(t, "Expected ", expected, " but got ", result, " for ", query)
+			return false
+		}
+	}
+	if read != expectedRead {
+		Log.Fail(t, "Expected to read ", expectedRead, " elements but read ", read, " for ", query)
+		return false
+	}
+	return true
+}
+
+func TestStream(t *testing.T) {
+	if !checkStream("select * from testproto where myint32>=10 limit 5 page 2", []int32{20, 21, 22, 23, 24}, 25, t) {
+		return
+	}
+	if !checkStream("select * from testproto where myint32<50 sort-by myint32 descending limit 3 page 1",
+		[]int32{46, 45, 44}, 100, t) {
+		return
+	}
+	if !checkStream("select * from testproto where myint32 in [7,3,5] sort-by myint32", []int32{3, 5, 7}, 100, t) {
+		return
+	}
+	//Large pages & limits do not overflow nor preallocate the heap
+	if !checkStream("select * from testproto where myint32>=90 sort-by myint32 limit 1000000 page 1000", []int32{}, 100, t) {
+		return
+	}
+	if !checkStream("select * from testproto where myint32>=97 sort-by myint32 limit 2147483647", []int32{97, 98, 99}, 100, t) {
+		return
+	}
+	if !checkStream("select * from testproto where myint32>=97 sort-by myint32 limit 10 page 2147483647", []int32{}, 100, t) {
+		return
+	}
+	if !checkStream("select * from testproto where myint32>=97 limit 2147483647 page 2147483647", []int32{}, 100, t) {
+		return
+	}
+	//Projection
+	q, _, e := createQuery("select mystring from testproto where myint32=42")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	read := 0
+	for elem := range q.Stream(streamInput(100, &read)) {
+		if elem.(*testtypes.TestProto).MyInt32 != 0 || elem.(*testtypes.TestProto).MyString == "" {
+			Log.Fail(t, "Expected only the selected columns")
+			return
+		}
+	}
+	//Channels
+	q, _, e = createQuery("select * from testproto where myint32>=90 sort-by myint32 descending limit 2")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	in := make(chan any)
+	go func() {
+		defer close(in)
+		for i := 0; i < 100; i++ {
+			node := CreateTestModelInstance(i)
+			node.MyInt32 = int32(i)
+			in <- node
+		}
+	}()
+	result := make([]int32, 0)
+	for elem := range q.StreamChan(context.Background(), in) {
+		result = append(result, elem.(*testtypes.TestProto).MyInt32)
+	}
+	if len(result) != 2 || result[0] != 99 || result[1] != 98 {
+		Log.Fail(t, "Unexpected channel stream result ", result)
+		return
+	}
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/saichler/l8ql/go/gsql/interpreter"
+	. "github.com/saichler/l8test/go/infra/t_resources"
+	"github.com/saichler/l8types/go/testtypes"
+)
+
+func TestSubscription(t *testing.T) {
+	collection, _ := interpreter.NewCollection[*testtypes.TestProto](cursorResources())
+	changes := make([]*interpreter.Change, 0)
+	subscription, e := collection.Subscribe("select mystring,myint32 from testproto where myint32>5", true, func(change *interpreter.Change) {
+		changes = append(changes, change)
+	})
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	put := func(i int, value int32, myInt64 int64) {
+		node := cursorModel(i)
+		node.MyInt32 = value
+		node.MyInt64 = myInt64
+		collection.Put(node)
+	}
+	put(1, 10, 0)  // added
+	put(2, 1, 0)   // no change, does not match
+	put(1, 11, 0)  // modified
+	put(1, 11, 64) // no change of the selected columns
+	put(2, 7, 0)   // added
+	put(1, 3, 0)   // removed
+	collection.Delete("string-2")
+	collection.Delete("string-1")
+
+	expected := []struct {
+		change interpreter.ChangeType
+		key    string
+		value  int32
+	}{
+		{interpreter.Added, "string-1", 10},
+		{interpreter.Modified, "string-1", 11},
+		{interpreter.Added, "string-2", 7},
+		{interpreter.Removed, "string-1", 11},
+		{interpreter.Removed, "string-2", 7},
+	}
+	if len(changes) != len(expected) {
+		Log.Fail(t, "Expected ", len(expected), " changes but got ", len(changes))
+		return
+	}
+	for i, change := range changes {
+		node := change.Element.(*testtypes.TestProto)
+		if change.Type != expected[i].change || change.Key != expected[i].key || node.MyInt32 != expected[i].value {
+			Log.Fail(t, "Unexpected change ", i, ": ", change.Type.String(), " ", change.Key, " ", node.MyInt32)
+			return
+		}
+		if node.MyInt64 != 0 {
+			Log.Fail(t, "Expected only the selected columns in change ", i)
+			return
+		}
+	}
+	if changes[1].Previous.(*testtypes.TestProto).MyInt32 != 10 {
+		Log.Fail(t, "Expected the previous element of the modified change")
+		return
+	}
+
+	subscription.Close()
+	put(3, 10, 0)
+	if len(changes) != len(expected) {
+		Log.Fail(t, "Expected no changes after the subscription was closed")
+		return
+	}
+	_, e = collection.Subscribe("select * from testprotosub", false, func(change *interpreter.Change) {})
+	if e == nil {
+		Log.Fail(t, "Expected an error for a subscription of another type")
+		return
+	}
+}
+
+func TestSubscriptionCloseWaitsForHandler(t *testing.T) {
+	q, _, e := createQuery("select * from testproto where myint32>5")
+	if e != nil {
+		Log.Fail(t, e)
+		return
+	}
+	subscriptions := interpreter.NewSubscriptions()
+	entered := make(chan bool)
+	release := make(chan bool)
+	closed := atomic.Bool{}
+	late := atomic.Int32{}
+	subscription := subscriptions.Subscribe(q, false, func(change *interpreter.Change) {
+		if closed.Load() {
+			late.Add(1)
+		}
+		if change.Key == "blocking" {
+			entered <- true
+			<-release
+		}
+	})
+	node := CreateTestModelInstance(1)
+	node.MyInt32 = 10
+	go subscriptions.Put("blocking", nil, node)
+	<-entered
+	done := make(chan bool)
+	go func() {
+		subscription.Close()
+		closed.Store(true)
+		close(done)
+	}()
+	select {
+	case <-done:
+		Log.Fail(t, "Expected Close to wait for the running handler")
+		return
+	case <-time.After(50 * time.Millisecond):
+	}
+	//writes of other goroutines while Close is waiting
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				subscriptions.Put("other", nil, node)
+			}
+		}()
+	}
+	close(release)
+	<-done
+	wg.Wait()
+	if late.Load() != 0 {
+		Log.Fail(t, "Expected no handler calls after Close returned but got ", late.Load())
+		return
+	}
+}